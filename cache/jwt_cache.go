@@ -4,28 +4,103 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+	"github.com/golang-jwt/jwt/v5"
 )
 
-// The line `var jwt_cache = cache.GetClient()` is initializing a variable `jwt_cache` with the value
-// returned by the `GetClient()` function from the `cache` package. This line is likely setting up a
-// connection to a cache server or creating a cache client object that will be used for storing and
-// retrieving data from the cache.
+// The `revokedTokenPrefix` constant is the prefix of the denylist keys. Every revoked token has its own
+// key, named after the `jti` claim of the token, that expires together with the token.
+const revokedTokenPrefix = "revoked_token:"
+
+// The `tokensNotBeforePrefix` constant is the prefix of the keys that hold the time before which the
+// tokens of a subject have been revoked, named after the subject.
+const tokensNotBeforePrefix = "tokens_not_before:"
+
+// The `legacyRevokedTokens` constant is the name of the list the revoked tokens used to be pushed onto.
+// It is migrated to the denylist when the cache client is initialized.
+const legacyRevokedTokens = "revoked_tokens"
+
+// The `legacyTokenLifetime` is the time a revoked token stays on the denylist if its expiration time
+// can not be read from the token. It is the lifetime of the legacy revoked token list.
+const legacyTokenLifetime = 7 * 24 * time.Hour
 
-// The function RevokedToken adds a token to a list of revoked tokens in a cache.
+// The `parser` is used to read the claims of a token without verifying it. The tokens are verified
+// by the `security` package, the denylist only needs their `jti` and `exp` claims.
+var parser = jwt.NewParser()
+
+// The function RevokedToken adds a token to the denylist until the token expires.
 func RevokeToken(token string) {
-	err := client.RPush(context.Background(), "revoked_tokens", token).Err()
+	key, ttl := denylistEntry(token)
+	if ttl <= 0 {
+		return
+	}
+
+	err := client.Set(context.Background(), key, 1, ttl).Err()
 	if err != nil {
 		fmt.Println(err)
 	}
-	client.Expire(context.Background(), "revoked_tokens", 7*24*60*60*time.Second)
 }
 
-// The IsTokenRevoked function checks if a given token is revoked by querying a cache.
+// The IsTokenRevoked function checks if a given token is on the denylist.
 func IsTokenRevoked(token string) bool {
-	revoked_tokens, error := client.LRem(context.Background(), "revoked_tokens", 1, token).Result()
-	if error != nil {
+	key, _ := denylistEntry(token)
+	revoked, err := client.Exists(context.Background(), key).Result()
+	if err != nil {
 		return false
 	}
 
-	return revoked_tokens == 1
+	return revoked == 1
+}
+
+// The function RevokeTokensIssuedBefore revokes every token of the subject that has been issued before
+// the given time. The revocation is kept for the given time to live, after which all of those tokens
+// have expired.
+func RevokeTokensIssuedBefore(subject string, t time.Time, ttl time.Duration) error {
+	return client.Set(context.Background(), tokensNotBeforePrefix+subject, t.Unix(), ttl).Err()
+}
+
+// The function TokensNotBefore returns the time before which the tokens of the subject have been
+// revoked, which is the zero time if they have not been.
+func TokensNotBefore(subject string) time.Time {
+	seconds, err := client.Get(context.Background(), tokensNotBeforePrefix+subject).Int64()
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// The function denylistEntry returns the denylist key of a token and the time until the token expires.
+// Tokens issued before the `jti` claim existed are keyed by their digest instead.
+func denylistEntry(token string) (string, time.Duration) {
+	var claims jwt.RegisteredClaims
+	if _, _, err := parser.ParseUnverified(token, &claims); err != nil {
+		return revokedTokenPrefix + utils.TokenDigest(token), legacyTokenLifetime
+	}
+
+	key := revokedTokenPrefix + claims.ID
+	if claims.ID == "" {
+		key = revokedTokenPrefix + utils.TokenDigest(token)
+	}
+	if claims.ExpiresAt == nil {
+		return key, legacyTokenLifetime
+	}
+	return key, time.Until(claims.ExpiresAt.Time)
+}
+
+// The function migrateRevokedTokens moves the tokens of the legacy revoked token list to the denylist
+// and deletes the list. Tokens that have already expired are dropped.
+func migrateRevokedTokens() {
+	ctx := context.Background()
+	tokens, err := client.LRange(ctx, legacyRevokedTokens, 0, -1).Result()
+	if err != nil || len(tokens) == 0 {
+		return
+	}
+
+	for _, token := range tokens {
+		RevokeToken(token)
+	}
+	if err := client.Del(ctx, legacyRevokedTokens).Err(); err != nil {
+		fmt.Println(err)
+	}
 }
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// The keys of the login throttle of an account, named after the subject of the user: the number of
+// consecutive failed logins, the back-off before the next attempt is allowed, which also holds the ID of
+// the attempt in progress, and the ID of the lockout of the account.
+const (
+	loginFailuresPrefix = "login_failures:"
+	loginBackoffPrefix  = "login_backoff:"
+	accountLockPrefix   = "account_lock:"
+)
+
+// The function RecordLoginFailure counts a failed login of the subject and returns the number of failed
+// logins since the last successful one. The count is forgotten after the given window without failures.
+func RecordLoginFailure(subject string, window time.Duration) (int64, error) {
+	ctx := context.Background()
+	count, err := client.Incr(ctx, loginFailuresPrefix+subject).Result()
+	if err != nil {
+		return 0, err
+	}
+	client.Expire(ctx, loginFailuresPrefix+subject, window)
+	return count, nil
+}
+
+// The `releaseAttemptScript` deletes the back-off key of an account only if it still holds the ID of
+// the given login attempt, so that a back-off set in the meantime is kept.
+var releaseAttemptScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// The function ReserveLoginAttempt atomically reserves the next login attempt of the subject, so that
+// only one attempt at a time can be made. It returns false if there is a back-off or another attempt in
+// progress. The reservation ends after the given timeout if it is not released or replaced by a
+// back-off before.
+func ReserveLoginAttempt(subject string, id string, timeout time.Duration) (bool, error) {
+	return client.SetNX(context.Background(), loginBackoffPrefix+subject, id, timeout).Result()
+}
+
+// The function ReleaseLoginAttempt ends the reservation of the login attempt with the given ID.
+func ReleaseLoginAttempt(subject string, id string) error {
+	return releaseAttemptScript.Run(context.Background(), client, []string{loginBackoffPrefix + subject}, id).Err()
+}
+
+// The function SetLoginBackoff sets the time the subject has to wait before the next login attempt. It
+// replaces the reservation of the attempt in progress.
+func SetLoginBackoff(subject string, delay time.Duration) error {
+	return client.Set(context.Background(), loginBackoffPrefix+subject, 1, delay).Err()
+}
+
+// The function LoginBackoff returns the time the subject still has to wait before the next login
+// attempt, which is zero if there is no back-off.
+func LoginBackoff(subject string) time.Duration {
+	return remaining(loginBackoffPrefix + subject)
+}
+
+// The function LockAccount locks the account of the subject for the given duration. The ID identifies
+// the lockout, so that an unlock link only lifts the lockout it was sent for.
+func LockAccount(subject string, id string, ttl time.Duration) error {
+	return client.Set(context.Background(), accountLockPrefix+subject, id, ttl).Err()
+}
+
+// The function AccountLock returns the ID of the lockout of the account of the subject and the time
+// until it ends. The ID is empty if the account is not locked.
+func AccountLock(subject string) (string, time.Duration) {
+	ctx := context.Background()
+	id, err := client.Get(ctx, accountLockPrefix+subject).Result()
+	if err != nil {
+		return "", 0
+	}
+	ttl := remaining(accountLockPrefix + subject)
+	if ttl == 0 {
+		return "", 0
+	}
+	return id, ttl
+}
+
+// The function ClearLoginFailures forgets the failed logins, the back-off and the lockout of the
+// subject.
+func ClearLoginFailures(subject string) error {
+	return client.Del(context.Background(), loginFailuresPrefix+subject, loginBackoffPrefix+subject, accountLockPrefix+subject).Err()
+}
+
+// The function remaining returns the time to live of the key, or zero if the key does not exist or
+// does not expire.
+func remaining(key string) time.Duration {
+	ttl, err := client.PTTL(context.Background(), key).Result()
+	if err != nil || ttl < 0 {
+		return 0
+	}
+	return ttl
+}
@@ -0,0 +1,21 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// The `magicLinkPrefix` constant is the prefix of the keys that hold the subjects of the magic links
+// that have not been used yet, named after the ID of the link token.
+const magicLinkPrefix = "magic_link:"
+
+// The function StoreMagicLink stores the subject a magic link was issued for until the link expires.
+func StoreMagicLink(id string, subject string, ttl time.Duration) error {
+	return client.Set(context.Background(), magicLinkPrefix+id, subject, ttl).Err()
+}
+
+// The function ConsumeMagicLink returns the subject a magic link was issued for and deletes it in the
+// same step, so that a link can only be used once.
+func ConsumeMagicLink(id string) (string, error) {
+	return client.GetDel(context.Background(), magicLinkPrefix+id).Result()
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+)
+
+// The `mfaChallengePrefix` constant is the prefix of the keys that hold the subjects of the pending MFA
+// challenges, named after the digest of the challenge token. The attempts made on a challenge are
+// counted under the same key with the `mfaAttemptsSuffix` suffix.
+const (
+	mfaChallengePrefix = "mfa_challenge:"
+	mfaAttemptsSuffix  = ":attempts"
+	totpStepPrefix     = "totp_step:"
+)
+
+// The function StoreMFAChallenge stores the subject an MFA challenge token was issued for until the
+// challenge expires.
+func StoreMFAChallenge(token string, subject string, ttl time.Duration) error {
+	return client.Set(context.Background(), mfaChallengePrefix+utils.TokenDigest(token), subject, ttl).Err()
+}
+
+// The function GetMFAChallenge returns the subject an MFA challenge token was issued for.
+func GetMFAChallenge(token string) (string, error) {
+	return client.Get(context.Background(), mfaChallengePrefix+utils.TokenDigest(token)).Result()
+}
+
+// The function ConsumeMFAChallenge returns the subject an MFA challenge token was issued for and
+// deletes the challenge in the same step, so that a challenge can only be completed once.
+func ConsumeMFAChallenge(token string) (string, error) {
+	key := mfaChallengePrefix + utils.TokenDigest(token)
+	subject, err := client.GetDel(context.Background(), key).Result()
+	client.Del(context.Background(), key+mfaAttemptsSuffix)
+	return subject, err
+}
+
+// The function FailMFAChallenge records a failed attempt on an MFA challenge and returns the number of
+// failed attempts made on it. The challenge is deleted once `limit` attempts have failed.
+func FailMFAChallenge(token string, limit int64) int64 {
+	ctx := context.Background()
+	key := mfaChallengePrefix + utils.TokenDigest(token)
+
+	attempts, err := client.Incr(ctx, key+mfaAttemptsSuffix).Result()
+	if err != nil {
+		fmt.Println(err)
+		return limit
+	}
+	if attempts == 1 {
+		client.Expire(ctx, key+mfaAttemptsSuffix, client.TTL(ctx, key).Val())
+	}
+	if attempts >= limit {
+		client.Del(ctx, key, key+mfaAttemptsSuffix)
+	}
+	return attempts
+}
+
+// The function UseTOTPStep records that a TOTP code of the given time step has been used by the
+// subject. It returns false if a code of that step has already been used, so that a code can not be
+// replayed while it is still valid.
+func UseTOTPStep(subject string, step int64, ttl time.Duration) bool {
+	key := fmt.Sprintf("%s%s:%d", totpStepPrefix, subject, step)
+	ok, err := client.SetNX(context.Background(), key, 1, ttl).Result()
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+	return ok
+}
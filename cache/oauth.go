@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+)
+
+// The `authorizationCodePrefix` constant is the prefix of the keys that hold the grants of the issued
+// authorization codes, named after the digest of the code.
+const authorizationCodePrefix = "oauth_code:"
+
+// The function StoreAuthorizationCode stores the grant an authorization code stands for until the code
+// expires.
+func StoreAuthorizationCode(code string, grant []byte, ttl time.Duration) error {
+	return client.Set(context.Background(), authorizationCodePrefix+utils.TokenDigest(code), grant, ttl).Err()
+}
+
+// The function ConsumeAuthorizationCode returns the grant an authorization code stands for and deletes
+// it in the same step, so that a code can only be redeemed once.
+func ConsumeAuthorizationCode(code string) ([]byte, error) {
+	return client.GetDel(context.Background(), authorizationCodePrefix+utils.TokenDigest(code)).Bytes()
+}
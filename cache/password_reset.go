@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+)
+
+// The `passwordResetPrefix` constant is the prefix of the keys that hold the subjects of the pending
+// password resets, named after the digest of the reset token. The digest of the latest token of a user
+// is kept under the `passwordResetUserPrefix` key of the user, so that requesting a new token
+// invalidates the previous one.
+const (
+	passwordResetPrefix     = "password_reset:"
+	passwordResetUserPrefix = "password_reset_user:"
+)
+
+// The function StorePasswordReset stores the subject a password reset token was issued for until the
+// token expires. The previous token of the subject is deleted.
+func StorePasswordReset(token string, subject string, ttl time.Duration) error {
+	ctx := context.Background()
+	digest := utils.TokenDigest(token)
+
+	if previous, err := client.GetSet(ctx, passwordResetUserPrefix+subject, digest).Result(); err == nil {
+		client.Del(ctx, passwordResetPrefix+previous)
+	}
+	client.Expire(ctx, passwordResetUserPrefix+subject, ttl)
+	return client.Set(ctx, passwordResetPrefix+digest, subject, ttl).Err()
+}
+
+// The function GetPasswordReset returns the subject a password reset token was issued for without
+// using the token up.
+func GetPasswordReset(token string) (string, error) {
+	return client.Get(context.Background(), passwordResetPrefix+utils.TokenDigest(token)).Result()
+}
+
+// The function ConsumePasswordReset returns the subject a password reset token was issued for and
+// deletes the token in the same step, so that a token can only be used once.
+func ConsumePasswordReset(token string) (string, error) {
+	ctx := context.Background()
+	subject, err := client.GetDel(ctx, passwordResetPrefix+utils.TokenDigest(token)).Result()
+	if err != nil {
+		return "", err
+	}
+	client.Del(ctx, passwordResetUserPrefix+subject)
+	return subject, nil
+}
@@ -28,4 +28,7 @@ func init() {
 		log.Fatal(err)
 		os.Exit(1)
 	}
+
+	// Deployments that still have the legacy revoked token list get it migrated to the denylist.
+	migrateRevokedTokens()
 }
@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// The `throttlePrefix` constant is the prefix of the keys that count the actions of the throttles.
+const throttlePrefix = "throttle:"
+
+// The function Throttle counts an action under the given key and reports if it is allowed: at most
+// `limit` actions are allowed per `window`. If the action is not allowed, the time until the window
+// ends is returned as well.
+func Throttle(key string, limit int64, window time.Duration) (bool, time.Duration) {
+	ctx := context.Background()
+	key = throttlePrefix + key
+
+	count, err := client.Incr(ctx, key).Result()
+	if err != nil {
+		fmt.Println(err)
+		return true, 0
+	}
+	if count == 1 {
+		client.Expire(ctx, key, window)
+	}
+	if count > limit {
+		ttl := client.TTL(ctx, key).Val()
+		if ttl < 0 {
+			// The key has no expiry if the `Expire` call above failed, so it is set again.
+			client.Expire(ctx, key, window)
+			ttl = window
+		}
+		return false, ttl
+	}
+	return true, 0
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/pkg/tokenfamily"
+	"github.com/redis/go-redis/v9"
+)
+
+// The `tokenFamilyPrefix` constant is the prefix of the keys that hold the state of a refresh token
+// family. Every family key stores the digest of the only refresh token of the family that may still be
+// exchanged.
+const tokenFamilyPrefix = "token_family:"
+
+// The errors returned by `RotateTokenFamily` when the presented refresh token can not be exchanged.
+var (
+	TokenFamilyNotFoundError = tokenfamily.NotFoundError
+	TokenReusedError         = tokenfamily.ReusedError
+)
+
+// The `swapScript` atomically swaps the current digest of a family for the next one, if the current
+// digest is the given one. It returns 0 if the family does not exist and -1 if the digest is another
+// one.
+var swapScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if not current then
+	return 0
+end
+if current ~= ARGV[1] then
+	return -1
+end
+redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+return 1
+`)
+
+// The `families` variable is the store of the refresh token families, which is kept in Redis.
+var families tokenfamily.Store = redisFamilies{}
+
+// The `redisFamilies` type implements the `tokenfamily.Store` interface with Redis.
+type redisFamilies struct{}
+
+func (redisFamilies) Set(family string, digest string, ttl time.Duration) error {
+	return client.Set(context.Background(), tokenFamilyPrefix+family, digest, ttl).Err()
+}
+
+func (redisFamilies) Get(family string) (string, bool, error) {
+	current, err := client.Get(context.Background(), tokenFamilyPrefix+family).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	return current, err == nil, err
+}
+
+func (redisFamilies) CompareAndSwap(family string, old string, new string, ttl time.Duration) (bool, bool, error) {
+	result, err := swapScript.Run(context.Background(), client, []string{tokenFamilyPrefix + family}, old, new, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, false, err
+	}
+	return result != 0, result == 1, nil
+}
+
+func (redisFamilies) Delete(family string) error {
+	return client.Del(context.Background(), tokenFamilyPrefix+family).Err()
+}
+
+// The function StartTokenFamily creates a new refresh token family whose current token is `token`. The
+// family expires together with the token.
+func StartTokenFamily(family string, token string, ttl time.Duration) {
+	if err := tokenfamily.Start(families, family, token, ttl); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// The function RotateTokenFamily replaces the current token of a family with `next`. It returns
+// `TokenReusedError` and revokes the family if `used` is not the current token of the family.
+func RotateTokenFamily(family string, used string, next string, ttl time.Duration) error {
+	return tokenfamily.Rotate(families, family, used, next, ttl)
+}
+
+// The function IsTokenFamilyCurrent checks if `token` is the current token of a family that has not
+// been revoked.
+func IsTokenFamilyCurrent(family string, token string) bool {
+	return tokenfamily.IsCurrent(families, family, token)
+}
+
+// The function RevokeTokenFamily revokes every refresh token of a family.
+func RevokeTokenFamily(family string) {
+	if err := families.Delete(family); err != nil {
+		fmt.Println(err)
+	}
+}
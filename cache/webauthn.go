@@ -0,0 +1,23 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+)
+
+// The `webAuthnChallengePrefix` constant is the prefix of the keys that hold the pending WebAuthn
+// ceremonies, named after the digest of their challenge.
+const webAuthnChallengePrefix = "webauthn_challenge:"
+
+// The function StoreWebAuthnChallenge stores a pending WebAuthn ceremony until its challenge expires.
+func StoreWebAuthnChallenge(challenge string, ceremony []byte, ttl time.Duration) error {
+	return client.Set(context.Background(), webAuthnChallengePrefix+utils.TokenDigest(challenge), ceremony, ttl).Err()
+}
+
+// The function ConsumeWebAuthnChallenge returns the pending WebAuthn ceremony of a challenge and deletes
+// it in the same step, so that a challenge can only be answered once.
+func ConsumeWebAuthnChallenge(challenge string) ([]byte, error) {
+	return client.GetDel(context.Background(), webAuthnChallengePrefix+utils.TokenDigest(challenge)).Bytes()
+}
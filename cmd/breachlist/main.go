@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"coderero.dev/projects/go/gin/hello/pkg/password"
+)
+
+// The breachlist command converts a list of common passwords, one per line, into the range files of a
+// local breached password list (see `password.BreachedList`). The lines are appended to the range files
+// in the output directory, so that it can also be added to a downloaded Pwned Passwords list.
+//
+//	go run ./cmd/breachlist -in common-passwords.txt -out ./breached
+func main() {
+	in := flag.String("in", "", "the file with one password per line")
+	out := flag.String("out", "", "the directory of the breached password list")
+	flag.Parse()
+
+	if *in == "" || *out == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(*in)
+	if err != nil {
+		fail(err)
+	}
+	defer file.Close()
+
+	ranges := make(map[string][]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		name, entry := password.RangeFile(line)
+		ranges[name] = append(ranges[name], entry)
+	}
+	if err := scanner.Err(); err != nil {
+		fail(err)
+	}
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		fail(err)
+	}
+	count := 0
+	for name, entries := range ranges {
+		f, err := os.OpenFile(filepath.Join(*out, name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			fail(err)
+		}
+		if _, err := f.WriteString(strings.Join(entries, "\n") + "\n"); err != nil {
+			fail(err)
+		}
+		if err := f.Close(); err != nil {
+			fail(err)
+		}
+		count += len(entries)
+	}
+
+	fmt.Printf("added %d passwords in %d range files\n", count, len(ranges))
+}
+
+// The `fail` function prints the error and exits.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/pkg/password"
+)
+
+// The number of hashes that are timed for every candidate; the fastest one is taken, so that the
+// measurement is not skewed by other work on the host.
+const rounds = 3
+
+// The calibrate command benchmarks the password hashing algorithm on the host and recommends the
+// strongest parameters that hash a password within the target latency, as the environment variables
+// the server reads, together with the number of hashes that fit in the memory budget at the same time.
+//
+//	go run ./cmd/calibrate -target 250ms -memory 1GiB
+//	go run ./cmd/calibrate -algorithm argon2id -argon2-memory 65536
+func main() {
+	algorithm := flag.String("algorithm", "scrypt", "the algorithm to calibrate: scrypt, argon2id or bcrypt")
+	target := flag.Duration("target", 250*time.Millisecond, "the longest time a hash may take")
+	budget := flag.String("memory", "1GiB", "the memory that may be used for hashing at the same time")
+	scryptR := flag.Int("scrypt-r", 8, "the scrypt block size")
+	argon2Memory := flag.Uint("argon2-memory", 19*1024, "the argon2id memory in KiB")
+	flag.Parse()
+
+	memoryBudget, err := parseBytes(*budget)
+	if err != nil || *target <= 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	fmt.Printf("calibrating %s for %s on %d CPUs\n\n", *algorithm, *target, runtime.NumCPU())
+
+	var env []string
+	var perHash uint64
+	switch *algorithm {
+	case "scrypt":
+		best := calibrate(*target, 10, 24, func(logN int) password.Algorithm {
+			return password.Scrypt{LogN: logN, R: *scryptR, P: 1, KeyLen: 32}
+		})
+		s := best.(password.Scrypt)
+		perHash = 128 * uint64(s.R) * (1 << s.LogN)
+		env = []string{
+			"PASSWORD_HASH_ALGORITHM=scrypt",
+			fmt.Sprintf("PASSWORD_SCRYPT_LN=%d", s.LogN),
+			fmt.Sprintf("PASSWORD_SCRYPT_R=%d", s.R),
+			"PASSWORD_SCRYPT_P=1",
+			"PASSWORD_SCRYPT_KEYLEN=32",
+		}
+	case "argon2id":
+		best := calibrate(*target, 1, 64, func(t int) password.Algorithm {
+			return password.Argon2id{Memory: uint32(*argon2Memory), Time: uint32(t), Threads: 1, KeyLen: 32}
+		})
+		a := best.(password.Argon2id)
+		perHash = uint64(a.Memory) * 1024
+		env = []string{
+			"PASSWORD_HASH_ALGORITHM=argon2id",
+			fmt.Sprintf("PASSWORD_ARGON2_MEMORY=%d", a.Memory),
+			fmt.Sprintf("PASSWORD_ARGON2_TIME=%d", a.Time),
+			"PASSWORD_ARGON2_THREADS=1",
+			"PASSWORD_ARGON2_KEYLEN=32",
+		}
+	case "bcrypt":
+		best := calibrate(*target, 10, 31, func(cost int) password.Algorithm {
+			return password.Bcrypt{Cost: cost}
+		})
+		perHash = 4 << 10
+		env = []string{
+			"PASSWORD_HASH_ALGORITHM=bcrypt",
+			fmt.Sprintf("PASSWORD_BCRYPT_COST=%d", best.(password.Bcrypt).Cost),
+		}
+	default:
+		fail(fmt.Errorf("unknown algorithm %q", *algorithm))
+	}
+
+	concurrency := memoryBudget / perHash
+	if concurrency < 1 {
+		fail(fmt.Errorf("a single hash takes %s, which does not fit in the memory budget of %s", formatBytes(perHash), *budget))
+	}
+	concurrency = min(concurrency, uint64(runtime.NumCPU()))
+	env = append(env, fmt.Sprintf("PASSWORD_HASH_CONCURRENCY=%d", concurrency))
+
+	fmt.Printf("\nevery hash takes %s of memory; recommended settings:\n\n", formatBytes(perHash))
+	for _, line := range env {
+		fmt.Println(line)
+	}
+}
+
+// The function `calibrate` times the algorithm built for every cost from `from` to `to` and returns the
+// strongest one that hashes within the target. The costs are tried in increasing order and the search
+// stops at the first one that is too slow.
+func calibrate(target time.Duration, from int, to int, build func(cost int) password.Algorithm) password.Algorithm {
+	var best password.Algorithm
+	for cost := from; cost <= to; cost++ {
+		algorithm := build(cost)
+		took, err := measure(algorithm)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("%+v: %s\n", algorithm, took.Round(time.Millisecond))
+		if took > target {
+			break
+		}
+		best = algorithm
+	}
+	if best == nil {
+		fail(fmt.Errorf("even the weakest parameters take longer than %s", target))
+	}
+	return best
+}
+
+// The function `measure` returns the shortest time the algorithm took to hash a password.
+func measure(algorithm password.Algorithm) (time.Duration, error) {
+	var fastest time.Duration
+	for i := 0; i < rounds; i++ {
+		start := time.Now()
+		if _, err := algorithm.Hash("calibration password"); err != nil {
+			return 0, err
+		}
+		if took := time.Since(start); i == 0 || took < fastest {
+			fastest = took
+		}
+	}
+	return fastest, nil
+}
+
+// The function `parseBytes` parses an amount of memory such as "512MiB" or "2GiB".
+func parseBytes(value string) (uint64, error) {
+	var amount uint64
+	var unit string
+	if _, err := fmt.Sscanf(value, "%d%s", &amount, &unit); err != nil {
+		return 0, err
+	}
+	units := map[string]uint64{"B": 1, "KiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30}
+	multiplier, ok := units[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", unit)
+	}
+	return amount * multiplier, nil
+}
+
+// The function `formatBytes` formats an amount of memory in the largest binary unit it fills.
+func formatBytes(n uint64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	}
+	return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+}
+
+// The `fail` function prints the error and exits.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
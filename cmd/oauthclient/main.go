@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/security"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+)
+
+// The grant types a client can be registered for.
+var grantTypes = map[string]bool{
+	"authorization_code": true,
+	"refresh_token":      true,
+	"client_credentials": true,
+}
+
+// The oauthclient command registers a new OAuth client and prints its client ID. Confidential clients
+// get a generated secret, which is printed once and only stored hashed. It reads the same `.env` file as
+// the server to connect to the database.
+//
+//	go run ./cmd/oauthclient -name "My SPA" -first-party -redirect-uri https://app.example.com/callback
+//	go run ./cmd/oauthclient -name "Billing" -confidential -grant-types client_credentials -scopes "users:read"
+func main() {
+	name := flag.String("name", "", "the name of the client")
+	redirectURIs := flag.String("redirect-uri", "", "the space separated redirect URIs of the client")
+	scopes := flag.String("scopes", "", "the space separated scopes the client may request")
+	grants := flag.String("grant-types", "authorization_code refresh_token", "the space separated grant types the client may use")
+	confidential := flag.Bool("confidential", false, "generate a client secret for the client")
+	firstParty := flag.Bool("first-party", false, "do not ask the users of the client for their consent")
+	flag.Parse()
+
+	client := &models.OAuthClient{
+		Name:         *name,
+		RedirectURIs: strings.Join(strings.Fields(*redirectURIs), " "),
+		Scopes:       strings.Join(strings.Fields(*scopes), " "),
+		GrantTypes:   strings.Join(strings.Fields(*grants), " "),
+		FirstParty:   *firstParty,
+	}
+	if client.Name == "" || client.GrantTypes == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	for _, grant := range strings.Fields(client.GrantTypes) {
+		if !grantTypes[grant] {
+			fail(fmt.Errorf("unknown grant type %q", grant))
+		}
+	}
+	if client.AllowsGrantType("authorization_code") && client.RedirectURIs == "" {
+		fail(fmt.Errorf("the authorization code grant requires a redirect URI"))
+	}
+	if client.AllowsGrantType("client_credentials") && !*confidential {
+		fail(fmt.Errorf("the client credentials grant requires a confidential client"))
+	}
+
+	clientID, err := utils.RandomID(16)
+	if err != nil {
+		fail(err)
+	}
+	client.ClientID = clientID
+
+	var secret string
+	if *confidential {
+		if secret, err = utils.RandomID(32); err != nil {
+			fail(err)
+		}
+		if client.Secret, err = security.HashPassword(secret); err != nil {
+			fail(err)
+		}
+	}
+
+	if _, err := client.Create(); err != nil {
+		fail(err)
+	}
+
+	fmt.Printf("client_id: %s\n", client.ClientID)
+	if secret != "" {
+		fmt.Printf("client_secret: %s\n", secret)
+	}
+}
+
+// The `fail` function prints the error and exits.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/security"
+)
+
+// The unlock command lifts the lockout of an account that has been locked after too many failed logins
+// and forgets its failed logins. It reads the same `.env` file as the server to connect to the database
+// and to Redis.
+//
+//	go run ./cmd/unlock -user jane@example.com
+func main() {
+	name := flag.String("user", "", "the username or email address of the user")
+	flag.Parse()
+
+	if *name == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	user := new(models.User).GetUser(*name, *name)
+	if user.ID == 0 {
+		fail(fmt.Errorf("user %q not found", *name))
+	}
+	if err := security.UnlockAccount(user); err != nil {
+		fail(err)
+	}
+
+	fmt.Printf("unlocked: %s\n", user.Username)
+}
+
+// The `fail` function prints the error and exits.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
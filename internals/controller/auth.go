@@ -1,10 +1,10 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
 	"coderero.dev/projects/go/gin/hello/cache"
 	"coderero.dev/projects/go/gin/hello/models"
@@ -36,9 +36,6 @@ func (AuthController) Register(c *gin.Context) {
 		return
 	}
 
-	// The `tokenActionType` variable is used to check if the user wants to return the access token and
-	tokenActionType := c.Query("return_token")
-
 	// The `previousTokens` function is used to check if the access token and refresh token are present in
 	// the request header or cookies. If they are present, they are revoked.
 	previousTokens(c)
@@ -62,6 +59,11 @@ func (AuthController) Register(c *gin.Context) {
 		return
 	}
 
+	// The password has to follow the password policy of the deployment.
+	if weakPassword(c, "password", register.Password, register.Username, register.Email) {
+		return
+	}
+
 	// The `loginValidation` function is used to check if the required fields for login are provided and
 	// returns true if there are any errors.
 	err3, msg := models.User{}.CheckForUser(register.Username, register.Email)
@@ -78,7 +80,8 @@ func (AuthController) Register(c *gin.Context) {
 	// The `HashPassword` function is used to hash the password provided by the user during registration.
 	hashPassword, err1 := security.HashPassword(register.Password)
 	if err1 != nil {
-		panic(err1)
+		hashingFailed(c, err1)
+		return
 	}
 
 	// The `Create` function is used to create a new user. It takes in the user object as a parameter.
@@ -94,35 +97,20 @@ func (AuthController) Register(c *gin.Context) {
 	// The `Create` function is used to create a new user. It takes in the user object as a parameter.
 	registeredObj := user.Create()
 
-	// The code snippet is generating access and refresh tokens for the registered user and setting them as
-	// cookies in the response. It then returns a JSON response with the status, status code, message, and
-	// the generated access and refresh tokens. This is typically done after a successful registration
-	// process to provide the user with authentication tokens for subsequent requests.
-	accessToken, refreshToken := security.GenerateAuthTokens(registeredObj)
-	if tokenActionType == "true" {
-		c.JSON(http.StatusCreated, types.Response{
-			Status: types.Status{
-				Code: http.StatusCreated,
-				Msg:  "registration successful",
-			},
-			Data: map[string]any{
-				"access_token":  accessToken,
-				"refresh_token": refreshToken,
-			}})
-		return
-	}
-
-	// The below code is setting two cookies named "__t" and "__rt" with the values of "accessToken" and
-	// "refreshToken" respectively. The cookies are set to expire after a certain duration (300 seconds for
-	// access token and 86400 seconds for refresh token).
-	setTokenInCookies(c, accessToken, refreshToken)
+	// The new account can only be used once the email address has been verified with the link that is
+	// sent to it, so no tokens are issued yet.
+	sendVerificationEmail(c, registeredObj)
 
 	// The code snippet is returning a JSON response with the status, status code, and message. This is
 	// typically done after a successful registration process.
 	c.JSON(http.StatusCreated, types.Response{
 		Status: types.Status{
 			Code: http.StatusCreated,
-			Msg:  "registration successful",
+			Msg:  "registration successful, please verify your email address",
+		},
+		Data: map[string]any{
+			"email":          registeredObj.Email,
+			"email_verified": false,
 		},
 	})
 }
@@ -197,23 +185,68 @@ func (AuthController) Login(c *gin.Context) {
 		return
 	}
 
+	// The failed logins of an account delay the next attempt, and too many of them lock the account,
+	// so that the password can not be guessed from many addresses. The attempt is reserved before the
+	// password is compared, so that concurrent attempts are made one after another.
+	attempt, ok := reserveLoginAttempt(c, registeredObj)
+	if !ok {
+		return
+	}
+
 	// The `ComparePassword` function is used to compare the password provided by the user during login
 	// with the hashed password stored in the database.
-	if security.ComparePassword(login.Password, registeredObj.Password) {
-		c.JSON(http.StatusBadRequest, types.Response{
-			Status: types.Status{
-				Code: http.StatusUnauthorized,
-				Msg:  "username or password is incorrect",
-			},
-		})
+	match, err := security.ComparePassword(login.Password, registeredObj.Password)
+	if err != nil {
+		releaseLoginAttempt(registeredObj, attempt)
+		hashingFailed(c, err)
+		return
+	}
+	if !match {
+		loginFailed(c, registeredObj, attempt)
+		return
+	}
+	releaseLoginAttempt(registeredObj, attempt)
+
+	// The password hash is upgraded while the password is known, if it was created with a weaker
+	// algorithm or weaker parameters than the ones configured.
+	if err := security.UpgradePasswordHash(registeredObj, login.Password); err != nil {
+		fmt.Println(err)
+	}
+
+	// Users can only log in once their email address has been verified.
+	if emailNotVerified(c, registeredObj) {
 		return
 	}
 
+	// Users with two-factor authentication get a challenge instead of the tokens, which they exchange
+	// for the tokens at the `/auth/mfa/verify` route once they have completed the second factor.
+	if security.IsMFAEnabled(registeredObj) {
+		mfaChallenge(c, registeredObj)
+		return
+	}
+
+	loginSuccessful(c, registeredObj, tokenActionType)
+}
+
+// The `loginSuccessful` function is used to log in a user whose credentials have been checked. It
+// creates a new session and returns the access and refresh tokens in the response body or as cookies.
+func loginSuccessful(c *gin.Context, registeredObj *models.User, tokenActionType string) {
+	// The failed logins are only forgotten once the login is complete, so that the wrong second factors
+	// after a correct password still count towards the lockout.
+	if err := security.LoginSucceeded(registeredObj); err != nil {
+		fmt.Println(err)
+	}
+
 	// This code snippet is generating access and refresh tokens for a registered user and setting them as
 	// cookies in the response. It then returns a JSON response with the status, status code, message, and
 	// the generated access and refresh tokens. This is typically done after a successful login process to
 	// provide the user with authentication tokens for subsequent requests.
-	accessToken, refreshToken := security.GenerateAuthTokens(registeredObj)
+	session, err := newSession(c, registeredObj)
+	if err != nil {
+		internalServerError(c)
+		return
+	}
+	accessToken, refreshToken := security.GenerateAuthTokens(registeredObj, session.ID)
 
 	// The code snippet is checking if the user wants to return the access token and refresh token in the
 	// response body or as cookies. If the user wants to return the tokens in the response body, the code
@@ -234,8 +267,8 @@ func (AuthController) Login(c *gin.Context) {
 	}
 
 	// The below code is setting two cookies named "__t" and "__rt" with the values of "accessToken" and
-	// "refreshToken" respectively. The cookies are set to expire after a certain duration (300 seconds for
-	// access token and 86400 seconds for refresh token).
+	// "refreshToken" respectively. The cookies are set to expire together with the tokens, as given by
+	// the token lifetime policy.
 	setTokenInCookies(c, accessToken, refreshToken)
 
 	// The code snippet is returning a JSON response with the status, status code, and message. This is
@@ -246,7 +279,6 @@ func (AuthController) Login(c *gin.Context) {
 			Msg:  "login successful",
 		},
 	})
-
 }
 
 // The `Logout` function is a method of the `AuthController` struct. It handles the logout process for
@@ -359,8 +391,8 @@ func (AuthController) RefreshToken(c *gin.Context) {
 	// The `sub` variable is used to get the subject from the claims.
 	sub, _ := claims.Claims.GetSubject()
 
-	var user *models.User
-	err = user.GetUserByEmail(sub)
+	// The `GetSubjectUser` function is used to get the user the subject identifies.
+	user, err := security.GetSubjectUser(sub)
 	if err != nil {
 		c.JSON(http.StatusNotFound, types.Response{
 			Status: types.Status{
@@ -371,17 +403,46 @@ func (AuthController) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// The `GenerateToken` function is used to generate a new access token for the user.
-	accessToken = security.GenerateToken(sub, time.Now().Add(time.Minute*5))
+	// The `RotateRefreshToken` function is used to exchange the refresh token for a new access token and
+	// a new refresh token of the same family and session. If the refresh token has already been
+	// exchanged, the whole family is revoked.
+	usedRefreshToken := refreshToken
+	accessToken, refreshToken, err = security.RotateRefreshToken(usedRefreshToken, user.UUID)
+	if errors.Is(err, cache.TokenReusedError) {
+		c.JSON(http.StatusUnauthorized, types.Response{
+			Status: types.Status{
+				Code: http.StatusUnauthorized,
+				Msg:  "refresh token reuse detected, please login again",
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, types.Response{
+			Status: types.Status{
+				Code: http.StatusUnauthorized,
+				Msg:  "unauthorized",
+			},
+		})
+		return
+	}
+
+	// Clients that keep their tokens in cookies get the rotated tokens as cookies as well, otherwise their
+	// next request would present the refresh token that has just been used.
+	if raw_refreshToken, _ := c.Request.Cookie("__rt"); raw_refreshToken != nil && raw_refreshToken.Value == usedRefreshToken {
+		setTokenInCookies(c, accessToken, refreshToken)
+	}
 
-	// The code snippet is returning the new access token in the response body.
+	// The code snippet is returning the new access token and the rotated refresh token in the response
+	// body.
 	c.JSON(http.StatusOK, types.Response{
 		Status: types.Status{
 			Code: http.StatusOK,
 			Msg:  "token refreshed",
 		},
 		Data: map[string]any{
-			"access_token": accessToken,
+			"access_token":  accessToken,
+			"refresh_token": refreshToken,
 		},
 	})
 }
@@ -500,7 +561,7 @@ func revokeTokenIfPresent(token string, raw_accessToken, raw_refreshToken *http.
 		if cache.IsTokenRevoked(refreshToken) || security.IsTokenExpired(refreshToken) {
 			return
 		}
-		cache.RevokeToken(refreshToken)
+		security.RevokeRefreshToken(refreshToken)
 	}
 }
 
@@ -512,7 +573,7 @@ func revoke(accessToken string, refreshToken string) {
 
 	// The code below is checking if the refresh token is not empty. If it is not empty, it then revokes
 	if refreshToken != "" {
-		cache.RevokeToken(refreshToken)
+		security.RevokeRefreshToken(refreshToken)
 	}
 }
 
@@ -549,9 +610,20 @@ func loginValidation(c *gin.Context, login types.Login) bool {
 	return false
 }
 
+// The `internalServerError` function writes the response for an error of the database, the cache or
+// another dependency the request can not be handled without.
+func internalServerError(c *gin.Context) {
+	c.JSON(http.StatusInternalServerError, types.Response{
+		Status: types.Status{
+			Code: http.StatusInternalServerError,
+			Msg:  "something went wrong",
+		},
+	})
+}
+
 // The `setTokenInCookies` function is used to set the access token and refresh token as cookies in the
-// response.
+// response. The cookies expire together with the tokens they hold.
 func setTokenInCookies(c *gin.Context, accessToken string, refreshToken string) {
-	c.SetCookie("__t", accessToken, 300, "/", "localhost", true, true)
-	c.SetCookie("__rt", refreshToken, 86400, "/", "localhost", true, true)
+	c.SetCookie("__t", accessToken, security.TokenLifetimes.AccessTokenMaxAge(), "/", "localhost", true, true)
+	c.SetCookie("__rt", refreshToken, security.TokenLifetimes.RefreshTokenMaxAge(), "/", "localhost", true, true)
 }
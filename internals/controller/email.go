@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/cache"
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/mail"
+	"coderero.dev/projects/go/gin/hello/pkg/security"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+	"coderero.dev/projects/go/gin/hello/types"
+	"github.com/gin-gonic/gin"
+)
+
+// The verification emails of an address are throttled to one per `verificationResendInterval` and
+// `verificationResendLimit` per day, so that the resend route can not be used to flood a mailbox.
+const (
+	verificationResendInterval = time.Minute
+	verificationResendLimit    = 5
+)
+
+// The `VerifyEmail` function is a method of the `AuthController` struct. It is the target of the link
+// sent to verify the email address of a new account.
+func (AuthController) VerifyEmail(c *gin.Context) {
+	user, err := security.VerifyEmail(c.Query("token"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, types.Response{
+			Status: types.Status{
+				Code: http.StatusBadRequest,
+				Msg:  "the verification link is invalid or has expired",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, types.Response{
+		Status: types.Status{
+			Code: http.StatusOK,
+			Msg:  "email address verified",
+		},
+		Data: map[string]any{
+			"email":          user.Email,
+			"email_verified": true,
+		},
+	})
+}
+
+// The `ResendVerification` function is a method of the `AuthController` struct. It sends a new
+// verification link to the given address if it belongs to an account that has not been verified. The
+// response is the same whether or not there is such an account, so that the route does not tell which
+// addresses are registered.
+func (AuthController) ResendVerification(c *gin.Context) {
+	var resend types.ResendVerification
+
+	// The `CheckContentType` function is used to check if the content type of the request is
+	if utils.CheckContentType(c, types.Application_json) {
+		return
+	}
+	if utils.DecodeJson(c, &resend) {
+		return
+	}
+	if err := validate.Struct(resend); err != nil {
+		c.JSON(http.StatusBadRequest, types.Response{
+			Status: types.Status{
+				Code: http.StatusBadRequest,
+				Msg:  "validation error",
+			},
+			Errors: utils.ConvertValidationErrors(err),
+		})
+		return
+	}
+
+	key := "verify_email:" + security.LinkBinding(resend.Email)
+	if throttled(c, key, 1, verificationResendInterval) || throttled(c, key+":daily", verificationResendLimit, 24*time.Hour) {
+		return
+	}
+
+	var user models.User
+	if err := user.GetUserByEmail(resend.Email); err == nil && !user.EmailVerified {
+		sendVerificationEmail(c, &user)
+	}
+
+	c.JSON(http.StatusAccepted, types.Response{
+		Status: types.Status{
+			Code: http.StatusAccepted,
+			Msg:  "if the address belongs to an unverified account, a new verification link has been sent",
+		},
+	})
+}
+
+// The `sendVerificationEmail` function sends the link that verifies the email address of the user. A
+// failure is only logged, as the user can ask for a new link.
+func sendVerificationEmail(c *gin.Context, user *models.User) {
+	link := publicURL(c) + "/api/v1/auth/verify-email?token=" + url.QueryEscape(security.EmailVerificationToken(user))
+	err := mail.Send(mail.VerificationMessage(user.Email, user.FirstName, link, security.EmailVerificationLifetime))
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// The `emailNotVerified` function checks if the email address of a user who is logging in has been
+// verified. It writes the response and returns true if it has not.
+func emailNotVerified(c *gin.Context, user *models.User) bool {
+	if user.EmailVerified {
+		return false
+	}
+
+	c.JSON(http.StatusForbidden, types.Response{
+		Status: types.Status{
+			Code: http.StatusForbidden,
+			Msg:  "email address not verified",
+		},
+		Errors: []types.APIError{
+			{
+				Field:   "email",
+				Message: "verify your email address with the link sent to it, or ask for a new link",
+			},
+		},
+	})
+	return true
+}
+
+// The `throttled` function counts an action under the given key and checks if it is allowed. It writes
+// a 429 response with the `Retry-After` header and returns true if it is not.
+func throttled(c *gin.Context, key string, limit int64, window time.Duration) bool {
+	allowed, retryAfter := cache.Throttle(key, limit, window)
+	if allowed {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+	c.JSON(http.StatusTooManyRequests, types.Response{
+		Status: types.Status{
+			Code: http.StatusTooManyRequests,
+			Msg:  "too many requests, please try again later",
+		},
+	})
+	return true
+}
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/mail"
+	"coderero.dev/projects/go/gin/hello/pkg/security"
+	"coderero.dev/projects/go/gin/hello/types"
+	"github.com/gin-gonic/gin"
+)
+
+// The `UnlockAccount` function is a method of the `AuthController` struct. It is the target of the link
+// sent to the user when the account is locked after too many failed logins, and lifts the lockout.
+func (AuthController) UnlockAccount(c *gin.Context) {
+	if _, err := security.UnlockAccountWithToken(c.Query("token")); err != nil {
+		if errors.Is(err, security.InvalidLinkTokenError) {
+			c.JSON(http.StatusBadRequest, types.Response{
+				Status: types.Status{
+					Code: http.StatusBadRequest,
+					Msg:  "the unlock link is invalid or has expired",
+				},
+			})
+			return
+		}
+		internalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, types.Response{
+		Status: types.Status{
+			Code: http.StatusOK,
+			Msg:  "account unlocked, please login",
+		},
+	})
+}
+
+// The `reserveLoginAttempt` function reserves an attempt of the user to log in with a password or a
+// second factor and returns its ID. It writes a 423 response if the account is locked, a 429 response if
+// the user has to wait after a failed login or for another attempt, or a 503 response if the attempts
+// can not be counted, and returns false in these cases.
+func reserveLoginAttempt(c *gin.Context, user *models.User) (string, bool) {
+	attempt, retryAfter, err := security.ReserveLoginAttempt(user)
+	switch {
+	case err == nil:
+		return attempt, true
+	case errors.Is(err, security.AccountLockedError):
+		accountLocked(c, retryAfter)
+	case errors.Is(err, security.LoginBackoffError):
+		c.Header("Retry-After", retryAfterSeconds(retryAfter))
+		c.JSON(http.StatusTooManyRequests, types.Response{
+			Status: types.Status{
+				Code: http.StatusTooManyRequests,
+				Msg:  "too many failed logins, please try again later",
+			},
+		})
+	default:
+		c.JSON(http.StatusServiceUnavailable, types.Response{
+			Status: types.Status{
+				Code: http.StatusServiceUnavailable,
+				Msg:  "logins can not be checked right now, please try again later",
+			},
+		})
+	}
+	return "", false
+}
+
+// The `releaseLoginAttempt` function ends the reservation of a login attempt that has not failed.
+func releaseLoginAttempt(user *models.User, attempt string) {
+	if err := security.ReleaseLoginAttempt(user, attempt); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// The `loginFailed` function records a failed login of the user and writes the response. The user is
+// sent a link that unlocks the account when the failure locks it.
+func loginFailed(c *gin.Context, user *models.User, attempt string) {
+	if recordLoginFailure(c, user, attempt) {
+		return
+	}
+	c.JSON(http.StatusUnauthorized, types.Response{
+		Status: types.Status{
+			Code: http.StatusUnauthorized,
+			Msg:  "username or password is incorrect",
+		},
+	})
+}
+
+// The `recordLoginFailure` function records a failed login of the user, which is a wrong password or a
+// wrong second factor. If the failure locks the account, the user is sent a link that unlocks it, the
+// 423 response is written and true is returned.
+func recordLoginFailure(c *gin.Context, user *models.User, attempt string) bool {
+	locked, err := security.LoginFailed(user, attempt)
+	if err != nil {
+		fmt.Println(err)
+	}
+	if !locked {
+		return false
+	}
+
+	link := publicURL(c) + "/api/v1/auth/unlock?token=" + url.QueryEscape(security.AccountUnlockToken(user))
+	go func(user models.User) {
+		if err := mail.Send(mail.AccountLockedMessage(user.Email, user.FirstName, link, security.Lockout.Duration)); err != nil {
+			fmt.Println(err)
+		}
+	}(*user)
+	accountLocked(c, security.Lockout.Duration)
+	return true
+}
+
+// The `accountLocked` function writes the 423 response of a locked account with the `Retry-After`
+// header.
+func accountLocked(c *gin.Context, retryAfter time.Duration) {
+	c.Header("Retry-After", retryAfterSeconds(retryAfter))
+	c.JSON(http.StatusLocked, types.Response{
+		Status: types.Status{
+			Code: http.StatusLocked,
+			Msg:  "account locked after too many failed logins",
+		},
+		Errors: []types.APIError{
+			{
+				Field:   "password",
+				Message: "try again later, open the unlock link sent to your email address, or reset your password",
+			},
+		},
+	})
+}
+
+// The `retryAfterSeconds` function formats a delay as the value of the `Retry-After` header, rounded
+// up to whole seconds.
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int((d + time.Second - 1) / time.Second))
+}
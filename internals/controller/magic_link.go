@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/mail"
+	"coderero.dev/projects/go/gin/hello/pkg/security"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+	"coderero.dev/projects/go/gin/hello/types"
+	"github.com/gin-gonic/gin"
+)
+
+// The magic link emails of an address are throttled like the verification emails.
+const (
+	magicLinkInterval = time.Minute
+	magicLinkLimit    = 5
+)
+
+// The `magicLinkCookie` constant is the name of the cookie that holds the nonce the magic links of a
+// browser are bound to.
+const magicLinkCookie = "__ml"
+
+// The `RequestMagicLink` function is a method of the `AuthController` struct. It sends a link that signs
+// the user in without a password to the given address, if it belongs to an account. The link only works
+// in the browser that asked for it, which is given a cookie with the nonce the link is bound to. The
+// response is the same whether or not there is such an account.
+func (AuthController) RequestMagicLink(c *gin.Context) {
+	var request types.MagicLink
+
+	// The `CheckContentType` function is used to check if the content type of the request is
+	if utils.CheckContentType(c, types.Application_json) {
+		return
+	}
+	if utils.DecodeJson(c, &request) {
+		return
+	}
+	if err := validate.Struct(request); err != nil {
+		c.JSON(http.StatusBadRequest, types.Response{
+			Status: types.Status{
+				Code: http.StatusBadRequest,
+				Msg:  "validation error",
+			},
+			Errors: utils.ConvertValidationErrors(err),
+		})
+		return
+	}
+
+	key := "magic_link:" + security.LinkBinding(request.Email)
+	if throttled(c, key, 1, magicLinkInterval) || throttled(c, key+":daily", magicLinkLimit, 24*time.Hour) {
+		return
+	}
+
+	// The cookie is set whether or not there is an account, so that the response does not tell either.
+	nonce, err := security.NewMagicLinkNonce()
+	if err != nil {
+		internalServerError(c)
+		return
+	}
+	c.SetCookie(magicLinkCookie, nonce, int(security.MagicLinkLifetime.Seconds()), "/", "localhost", true, true)
+
+	var user models.User
+	if err := user.GetUserByEmail(request.Email); err == nil {
+		token, err := security.IssueMagicLinkToken(&user, nonce)
+		if err != nil {
+			internalServerError(c)
+			return
+		}
+		link := publicURL(c) + "/api/v1/auth/magic-link/consume?token=" + url.QueryEscape(token)
+		if c.Query("return_token") == "true" {
+			link += "&return_token=true"
+		}
+		go func() {
+			if err := mail.Send(mail.MagicLinkMessage(user.Email, user.FirstName, link, security.MagicLinkLifetime)); err != nil {
+				fmt.Println(err)
+			}
+		}()
+	}
+
+	c.JSON(http.StatusAccepted, types.Response{
+		Status: types.Status{
+			Code: http.StatusAccepted,
+			Msg:  "if the address belongs to an account, a sign-in link has been sent",
+		},
+	})
+}
+
+// The `ConsumeMagicLink` function is a method of the `AuthController` struct. It is the target of the
+// magic links and logs the user in like the `Login` function, including the two-factor challenge and
+// the `return_token` query parameter.
+func (AuthController) ConsumeMagicLink(c *gin.Context) {
+	var nonce string
+	if cookie, err := c.Request.Cookie(magicLinkCookie); err == nil {
+		nonce = cookie.Value
+	}
+
+	user, err := security.ConsumeMagicLink(c.Query("token"), nonce)
+	if err != nil {
+		switch {
+		case errors.Is(err, security.MagicLinkBrowserError):
+			c.JSON(http.StatusForbidden, types.Response{
+				Status: types.Status{
+					Code: http.StatusForbidden,
+					Msg:  "open the sign-in link in the browser you asked for it from",
+				},
+			})
+		case errors.Is(err, security.InvalidMagicLinkError):
+			c.JSON(http.StatusBadRequest, types.Response{
+				Status: types.Status{
+					Code: http.StatusBadRequest,
+					Msg:  "the sign-in link is invalid, has expired or has already been used",
+				},
+			})
+		default:
+			internalServerError(c)
+		}
+		return
+	}
+
+	// The nonce is only good for one sign-in, and the tokens of a previous login are revoked like in
+	// the `Login` function.
+	c.SetCookie(magicLinkCookie, "", -1, "/", "localhost", true, true)
+	previousTokens(c)
+
+	if security.IsMFAEnabled(user) {
+		mfaChallenge(c, user)
+		return
+	}
+
+	loginSuccessful(c, user, c.Query("return_token"))
+}
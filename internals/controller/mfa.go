@@ -0,0 +1,344 @@
+package controller
+
+import (
+	"net/http"
+
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/security"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+	"coderero.dev/projects/go/gin/hello/types"
+	"github.com/gin-gonic/gin"
+)
+
+type MFAController struct{}
+
+// The `EnrollTOTP` function is a method of the `MFAController` struct. It starts the TOTP enrollment of
+// the logged in user and returns the secret and the `otpauth://` URI to add it to an authenticator
+// app. Two-factor authentication is only enabled once the secret has been confirmed.
+func (MFAController) EnrollTOTP(c *gin.Context) {
+	user, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	if user.TOTPEnabled {
+		c.JSON(http.StatusConflict, types.Response{
+			Status: types.Status{
+				Code: http.StatusConflict,
+				Msg:  "two-factor authentication is already enabled",
+			},
+		})
+		return
+	}
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		internalServerError(c)
+		return
+	}
+	if err := user.SetTOTPSecret(secret); err != nil {
+		internalServerError(c)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, types.Response{
+		Status: types.Status{
+			Code: http.StatusOK,
+			Msg:  "confirm the secret with a code to enable two-factor authentication",
+		},
+		Data: map[string]any{
+			"secret":      secret,
+			"otpauth_uri": security.TOTPURI(secret, user.Email),
+		},
+	})
+}
+
+// The `ConfirmTOTP` function is a method of the `MFAController` struct. It enables two-factor
+// authentication once the user has proven with a first code that the authenticator app has the secret.
+// The response holds the recovery codes of the user, which are only shown this once.
+func (MFAController) ConfirmTOTP(c *gin.Context) {
+	user, code, ok := totpRequest(c)
+	if !ok {
+		return
+	}
+
+	if user.TOTPEnabled || user.TOTPSecret == "" {
+		c.JSON(http.StatusConflict, types.Response{
+			Status: types.Status{
+				Code: http.StatusConflict,
+				Msg:  "no pending two-factor enrollment",
+			},
+		})
+		return
+	}
+	if !security.ValidateTOTP(user.UUID, user.TOTPSecret, code) {
+		invalidTOTPCode(c)
+		return
+	}
+	if err := user.EnableTOTP(); err != nil {
+		internalServerError(c)
+		return
+	}
+	codes, err := security.GenerateRecoveryCodes(user)
+	if err != nil {
+		hashingFailed(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, types.Response{
+		Status: types.Status{
+			Code: http.StatusOK,
+			Msg:  "two-factor authentication enabled",
+		},
+		Data: map[string]any{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// The `DisableTOTP` function is a method of the `MFAController` struct. It disables two-factor
+// authentication; a current code is required, so that a stolen session alone can not remove the second
+// factor.
+func (MFAController) DisableTOTP(c *gin.Context) {
+	user, code, ok := totpRequest(c)
+	if !ok {
+		return
+	}
+
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusConflict, types.Response{
+			Status: types.Status{
+				Code: http.StatusConflict,
+				Msg:  "two-factor authentication is not enabled",
+			},
+		})
+		return
+	}
+	if !security.ValidateTOTP(user.UUID, user.TOTPSecret, code) {
+		invalidTOTPCode(c)
+		return
+	}
+	if err := user.DisableTOTP(); err != nil {
+		internalServerError(c)
+		return
+	}
+	if err := security.DeleteRecoveryCodes(user); err != nil {
+		internalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, types.Response{
+		Status: types.Status{
+			Code: http.StatusOK,
+			Msg:  "two-factor authentication disabled",
+		},
+	})
+}
+
+// The `RecoveryCodes` function is a method of the `MFAController` struct. It returns the number of
+// recovery codes the logged in user has not used yet; the codes themselves are never shown again.
+func (MFAController) RecoveryCodes(c *gin.Context) {
+	user, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, types.Response{
+		Status: types.Status{
+			Code: http.StatusOK,
+			Msg:  "ok",
+		},
+		Data: map[string]any{
+			"remaining": security.RecoveryCodesLeft(user),
+		},
+	})
+}
+
+// The `RegenerateRecoveryCodes` function is a method of the `MFAController` struct. It replaces the
+// recovery codes of the logged in user with a new set, which is only shown this once. A current TOTP
+// code is required, like for disabling two-factor authentication.
+func (MFAController) RegenerateRecoveryCodes(c *gin.Context) {
+	user, code, ok := totpRequest(c)
+	if !ok {
+		return
+	}
+
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusConflict, types.Response{
+			Status: types.Status{
+				Code: http.StatusConflict,
+				Msg:  "two-factor authentication is not enabled",
+			},
+		})
+		return
+	}
+	if !security.ValidateTOTP(user.UUID, user.TOTPSecret, code) {
+		invalidTOTPCode(c)
+		return
+	}
+
+	codes, err := security.GenerateRecoveryCodes(user)
+	if err != nil {
+		hashingFailed(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, types.Response{
+		Status: types.Status{
+			Code: http.StatusOK,
+			Msg:  "recovery codes regenerated",
+		},
+		Data: map[string]any{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// The `Verify` function is a method of the `MFAController` struct. It exchanges the MFA challenge
+// returned by `Login` and a TOTP code or a recovery code for the access and refresh tokens. A recovery
+// code is burned once it has been used. A challenge is ended after too many wrong codes, so that the
+// codes can not be guessed.
+func (MFAController) Verify(c *gin.Context) {
+	var verify types.MFAVerify
+
+	// The `CheckContentType` function is used to check if the content type of the request is
+	if utils.CheckContentType(c, types.Application_json) {
+		return
+	}
+	if utils.DecodeJson(c, &verify) {
+		return
+	}
+	if err := validate.Struct(verify); err != nil {
+		c.JSON(http.StatusBadRequest, types.Response{
+			Status: types.Status{
+				Code: http.StatusBadRequest,
+				Msg:  "validation error",
+			},
+			Errors: utils.ConvertValidationErrors(err),
+		})
+		return
+	}
+
+	user, err := security.GetMFAChallengeUser(verify.MFAToken)
+	if err != nil {
+		invalidMFAChallenge(c)
+		return
+	}
+
+	// A wrong second factor counts as a failed login of the account, so that the code can not be
+	// guessed by starting new challenges with the password.
+	attempt, ok := reserveLoginAttempt(c, user)
+	if !ok {
+		return
+	}
+
+	var valid bool
+	if verify.RecoveryCode != "" {
+		valid, err = security.UseRecoveryCode(user, verify.RecoveryCode)
+		if err != nil {
+			releaseLoginAttempt(user, attempt)
+			hashingFailed(c, err)
+			return
+		}
+	} else {
+		valid = security.ValidateTOTP(user.UUID, user.TOTPSecret, verify.Code)
+	}
+	if !valid {
+		if recordLoginFailure(c, user, attempt) {
+			return
+		}
+		if security.FailMFAChallenge(verify.MFAToken) {
+			invalidMFAChallenge(c)
+			return
+		}
+		invalidTOTPCode(c)
+		return
+	}
+	releaseLoginAttempt(user, attempt)
+	if err := security.CompleteMFAChallenge(verify.MFAToken); err != nil {
+		invalidMFAChallenge(c)
+		return
+	}
+
+	loginSuccessful(c, user, c.Query("return_token"))
+}
+
+// The `mfaChallenge` function responds to a login whose password is correct with an MFA challenge.
+func mfaChallenge(c *gin.Context, user *models.User) {
+	token, err := security.IssueMFAChallenge(user)
+	if err != nil {
+		internalServerError(c)
+		return
+	}
+
+	methods := []string{"totp"}
+	if security.RecoveryCodesLeft(user) > 0 {
+		methods = append(methods, "recovery_code")
+	}
+
+	c.JSON(http.StatusOK, types.Response{
+		Status: types.Status{
+			Code: http.StatusOK,
+			Msg:  "two-factor authentication required",
+		},
+		Data: map[string]any{
+			"mfa_required": true,
+			"mfa_token":    token,
+			"mfa_methods":  methods,
+		},
+	})
+}
+
+// The `totpRequest` function returns the logged in user and the TOTP code of the request body. It
+// writes the response and returns false if the request is invalid.
+func totpRequest(c *gin.Context) (*models.User, string, bool) {
+	var body types.TOTPCode
+
+	// The `CheckContentType` function is used to check if the content type of the request is
+	if utils.CheckContentType(c, types.Application_json) {
+		return nil, "", false
+	}
+	if utils.DecodeJson(c, &body) {
+		return nil, "", false
+	}
+	if err := validate.Struct(body); err != nil {
+		c.JSON(http.StatusBadRequest, types.Response{
+			Status: types.Status{
+				Code: http.StatusBadRequest,
+				Msg:  "validation error",
+			},
+			Errors: utils.ConvertValidationErrors(err),
+		})
+		return nil, "", false
+	}
+
+	user, ok := sessionUser(c)
+	if !ok {
+		return nil, "", false
+	}
+	return user, body.Code, true
+}
+
+// The `invalidTOTPCode` function returns a JSON response indicating that the TOTP code is wrong.
+func invalidTOTPCode(c *gin.Context) {
+	c.JSON(http.StatusUnauthorized, types.Response{
+		Status: types.Status{
+			Code: http.StatusUnauthorized,
+			Msg:  "invalid two-factor code",
+		},
+	})
+}
+
+// The `invalidMFAChallenge` function returns a JSON response indicating that the MFA challenge is
+// invalid or has expired, so that the user has to log in again.
+func invalidMFAChallenge(c *gin.Context) {
+	c.JSON(http.StatusUnauthorized, types.Response{
+		Status: types.Status{
+			Code: http.StatusUnauthorized,
+			Msg:  "invalid or expired two-factor challenge, please login again",
+		},
+	})
+}
@@ -0,0 +1,532 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"coderero.dev/projects/go/gin/hello/cache"
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/oauth"
+	"coderero.dev/projects/go/gin/hello/pkg/security"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+	"coderero.dev/projects/go/gin/hello/types"
+	"github.com/gin-gonic/gin"
+)
+
+type OAuthController struct{}
+
+// The `Authorize` function is a method of the `OAuthController` struct. It implements the authorization
+// endpoint of the authorization code grant (RFC 6749, section 4.1) with mandatory PKCE (RFC 7636). The
+// user is the one logged in with the existing session, so the client never sees the password of the
+// user. Errors are only redirected to the client once the client and the redirect URI are known to be
+// valid.
+func (OAuthController) Authorize(c *gin.Context) {
+	var client models.OAuthClient
+	if err := client.GetClient(c.Query("client_id")); err != nil {
+		oauthError(c, http.StatusBadRequest, "invalid_client", "unknown client")
+		return
+	}
+
+	redirectURI, ok := client.RedirectURI(c.Query("redirect_uri"))
+	if !ok {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "the redirect_uri is not registered for the client")
+		return
+	}
+
+	state := c.Query("state")
+	if !client.AllowsGrantType("authorization_code") {
+		oauthRedirect(c, redirectURI, map[string]string{
+			"error":             "unauthorized_client",
+			"error_description": "the client may not use the authorization code grant",
+			"state":             state,
+		})
+		return
+	}
+	if c.Query("response_type") != "code" {
+		oauthRedirect(c, redirectURI, map[string]string{
+			"error":             "unsupported_response_type",
+			"error_description": "only the authorization code grant is supported",
+			"state":             state,
+		})
+		return
+	}
+
+	challenge := c.Query("code_challenge")
+	if c.Query("code_challenge_method") != "S256" || !oauth.IsValidCodeChallenge(challenge) {
+		oauthRedirect(c, redirectURI, map[string]string{
+			"error":             "invalid_request",
+			"error_description": "a code_challenge with the S256 code_challenge_method is required",
+			"state":             state,
+		})
+		return
+	}
+
+	scope, ok := client.GrantedScope(c.Query("scope"))
+	if !ok {
+		oauthRedirect(c, redirectURI, map[string]string{
+			"error":             "invalid_scope",
+			"error_description": "the requested scope is not allowed for the client",
+			"state":             state,
+		})
+		return
+	}
+
+	user, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	// Only the clients of the deployment itself may act on behalf of the user without asking. For any
+	// other client the front end shows the user what the client asks for and submits the decision to the
+	// `Consent` function before the authorization request is repeated.
+	if !client.FirstParty {
+		var consent models.OAuthConsent
+		if consent.GetConsent(user.ID, client.ClientID) != nil || !consent.Covers(scope) {
+			c.Header("Cache-Control", "no-store")
+			c.JSON(http.StatusOK, types.Response{
+				Status: types.Status{
+					Code: http.StatusOK,
+					Msg:  "consent required",
+				},
+				Data: map[string]any{
+					"client_id":   client.ClientID,
+					"client_name": client.Name,
+					"scope":       scope,
+				},
+			})
+			return
+		}
+	}
+
+	code, err := security.IssueAuthorizationCode(security.AuthorizationCode{
+		ClientID:      client.ClientID,
+		RedirectURI:   c.Query("redirect_uri"),
+		Subject:       user.UUID,
+		Scope:         scope,
+		CodeChallenge: challenge,
+		Nonce:         c.Query("nonce"),
+	})
+	if err != nil {
+		oauthRedirect(c, redirectURI, map[string]string{
+			"error": "server_error",
+			"state": state,
+		})
+		return
+	}
+
+	oauthRedirect(c, redirectURI, map[string]string{
+		"code":  code,
+		"state": state,
+	})
+}
+
+// The `Consent` function is a method of the `OAuthController` struct. It records the decision of the
+// logged in user on an authorization request that needs consent. An approval is remembered for the
+// client and the scope, so that the repeated authorization request is answered with a code. A denial is
+// answered with the redirect that tells the client the user has denied the request (RFC 6749, section
+// 4.1.2.1).
+func (OAuthController) Consent(c *gin.Context) {
+	var request types.OAuthConsent
+
+	// The `CheckContentType` function is used to check if the content type of the request is
+	if utils.CheckContentType(c, types.Application_json) {
+		return
+	}
+	if utils.DecodeJson(c, &request) {
+		return
+	}
+	if err := validate.Struct(request); err != nil {
+		c.JSON(http.StatusBadRequest, types.Response{
+			Status: types.Status{
+				Code: http.StatusBadRequest,
+				Msg:  "validation error",
+			},
+			Errors: utils.ConvertValidationErrors(err),
+		})
+		return
+	}
+
+	var client models.OAuthClient
+	if err := client.GetClient(request.ClientID); err != nil {
+		oauthError(c, http.StatusBadRequest, "invalid_client", "unknown client")
+		return
+	}
+	redirectURI, ok := client.RedirectURI(request.RedirectURI)
+	if !ok {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "the redirect_uri is not registered for the client")
+		return
+	}
+	scope, ok := client.GrantedScope(request.Scope)
+	if !ok {
+		oauthError(c, http.StatusBadRequest, "invalid_scope", "the requested scope is not allowed for the client")
+		return
+	}
+
+	user, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	if !request.Approve {
+		target, err := url.Parse(redirectURI)
+		if err != nil {
+			oauthError(c, http.StatusBadRequest, "invalid_request", "the redirect_uri is invalid")
+			return
+		}
+		query := target.Query()
+		query.Set("error", "access_denied")
+		query.Set("error_description", "the user denied the request")
+		if request.State != "" {
+			query.Set("state", request.State)
+		}
+		target.RawQuery = query.Encode()
+
+		c.JSON(http.StatusOK, types.Response{
+			Status: types.Status{
+				Code: http.StatusOK,
+				Msg:  "consent denied",
+			},
+			Data: map[string]any{
+				"redirect_uri": target.String(),
+			},
+		})
+		return
+	}
+
+	// The scopes the user has consented to before are kept, so that approving a smaller scope does not
+	// take back an earlier consent.
+	var previous models.OAuthConsent
+	previous.GetConsent(user.ID, client.ClientID)
+	consent := models.OAuthConsent{UserID: user.ID, ClientID: client.ClientID, Scope: previous.Scope}
+	consent.Add(scope)
+	if err := consent.Save(); err != nil {
+		internalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, types.Response{
+		Status: types.Status{
+			Code: http.StatusOK,
+			Msg:  "consent granted, repeat the authorization request",
+		},
+	})
+}
+
+// The `Token` function is a method of the `OAuthController` struct. It implements the token endpoint
+// (RFC 6749, section 3.2) for the authorization code, the refresh token and the client credentials
+// grants. The client is authenticated before the grant is looked at and may only use the grant types it
+// is registered for.
+func (OAuthController) Token(c *gin.Context) {
+	if c.ContentType() != types.Application_form {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "Content-Type must be "+types.Application_form)
+		return
+	}
+
+	grantType := c.PostForm("grant_type")
+	var grant func(*gin.Context, *models.OAuthClient)
+	switch grantType {
+	case "authorization_code":
+		grant = authorizationCodeGrant
+	case "refresh_token":
+		grant = refreshTokenGrant
+	case "client_credentials":
+		grant = clientCredentialsGrant
+	default:
+		oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "the grant_type is not supported")
+		return
+	}
+
+	client, ok := authenticateClient(c)
+	if !ok {
+		return
+	}
+	if !client.AllowsGrantType(grantType) {
+		oauthError(c, http.StatusBadRequest, "unauthorized_client", "the client may not use the grant_type")
+		return
+	}
+	grant(c, client)
+}
+
+// The `Introspect` function is a method of the `OAuthController` struct. It implements the token
+// introspection endpoint (RFC 7662), which lets other services check if a token is active, taking the
+// revocations that a local signature check can not see into account. The response is not wrapped in a
+// `types.Response`, because its format is defined by the RFC.
+func (OAuthController) Introspect(c *gin.Context) {
+	if c.ContentType() != types.Application_form {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "Content-Type must be "+types.Application_form)
+		return
+	}
+
+	// The `token_type_hint` parameter is only a hint (RFC 7662, section 2.1) and is not needed, because
+	// every token issued by the server is introspected the same way.
+	token := c.PostForm("token")
+	if token == "" {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "the token parameter is required")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	claims, active := security.IntrospectToken(token)
+	if !active {
+		c.JSON(http.StatusOK, types.TokenIntrospection{Active: false})
+		return
+	}
+
+	introspection := types.TokenIntrospection{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		TokenType: "Bearer",
+		Sub:       claims.Subject,
+		Aud:       claims.Audience,
+		Iss:       claims.Issuer,
+		Jti:       claims.ID,
+	}
+	if claims.ExpiresAt != nil {
+		introspection.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		introspection.Iat = claims.IssuedAt.Unix()
+	}
+	if claims.NotBefore != nil {
+		introspection.Nbf = claims.NotBefore.Unix()
+	}
+	c.JSON(http.StatusOK, introspection)
+}
+
+// The `Revoke` function is a method of the `OAuthController` struct. It implements the token revocation
+// endpoint (RFC 7009), which lets clients that do not use cookies revoke their access and refresh tokens.
+// The endpoint responds with 200 for invalid and unknown tokens as well, as required by the RFC.
+func (OAuthController) Revoke(c *gin.Context) {
+	if c.ContentType() != types.Application_form {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "Content-Type must be "+types.Application_form)
+		return
+	}
+
+	token := c.PostForm("token")
+	if token == "" {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "the token parameter is required")
+		return
+	}
+
+	// The `token_type_hint` parameter ("access_token" or "refresh_token") may be ignored (RFC 7009,
+	// section 2.1). The type of the token is known from its claims, so the hint is not needed.
+	security.RevokeToken(token)
+
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
+
+// The `UserInfo` function is a method of the `OAuthController` struct. It implements the OpenID Connect
+// userinfo endpoint, which returns the claims about the user the access token was issued for. The
+// access token has to be issued with the `openid` scope; the `profile` and `email` scopes add the
+// claims mapped from the user.
+func (OAuthController) UserInfo(c *gin.Context) {
+	scope := c.GetString(types.ContextScope)
+	if !oauth.HasScope(scope, "openid") {
+		c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+		oauthError(c, http.StatusForbidden, "insufficient_scope", "the access token was not issued with the openid scope")
+		return
+	}
+
+	user, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	info := types.UserInfo{Sub: user.UUID}
+	if oauth.HasScope(scope, "profile") {
+		info.PreferredUsername = user.Username
+		info.GivenName = user.FirstName
+		info.FamilyName = user.LastName
+		info.Name = strings.TrimSpace(user.FirstName + " " + user.LastName)
+	}
+	if oauth.HasScope(scope, "email") {
+		info.Email = user.Email
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, info)
+}
+
+// The `authorizationCodeGrant` function exchanges an authorization code for tokens. The code is
+// redeemed before anything else is checked, so that a code can never be tried twice.
+func authorizationCodeGrant(c *gin.Context, client *models.OAuthClient) {
+	grant, err := security.RedeemAuthorizationCode(c.PostForm("code"))
+	if err != nil {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "the authorization code is invalid or has expired")
+		return
+	}
+
+	if grant.ClientID != client.ClientID || grant.RedirectURI != c.PostForm("redirect_uri") {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "the authorization code was issued to another client or redirect_uri")
+		return
+	}
+	if !oauth.VerifyCodeVerifier(c.PostForm("code_verifier"), grant.CodeChallenge) {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "the code_verifier does not match the code_challenge")
+		return
+	}
+
+	user, err := security.GetSubjectUser(grant.Subject)
+	if err != nil {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "the user no longer exists")
+		return
+	}
+
+	// Every authorization gets its own session, so that the user can see and revoke it like any other
+	// login.
+	session, err := newSession(c, user)
+	if err != nil {
+		oauthError(c, http.StatusInternalServerError, "server_error", "the session could not be created")
+		return
+	}
+	accessToken, refreshToken := security.GenerateOAuthTokens(user, session.ID, grant.ClientID, grant.Scope)
+	tokenResponse(c, accessToken, refreshToken, grant.Scope, idToken(c, user, grant.ClientID, grant.Scope, grant.Nonce))
+}
+
+// The `refreshTokenGrant` function exchanges a refresh token issued to a client for new tokens. The
+// refresh token is rotated like the refresh tokens of the `/auth/refresh` route.
+func refreshTokenGrant(c *gin.Context, client *models.OAuthClient) {
+	refreshToken := c.PostForm("refresh_token")
+	jwtToken, err := security.VerifyToken(refreshToken)
+	if err != nil || !jwtToken.Valid || cache.IsTokenRevoked(refreshToken) {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "the refresh token is invalid or has expired")
+		return
+	}
+
+	claims := jwtToken.Claims.(*security.Claims)
+	if claims.Family == "" || claims.ClientID == "" || claims.ClientID != client.ClientID {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "the refresh token was not issued to the client")
+		return
+	}
+
+	user, err := security.GetSubjectUser(claims.Subject)
+	if err != nil {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "the user no longer exists")
+		return
+	}
+
+	accessToken, refreshToken, err := security.RotateRefreshToken(refreshToken, user.UUID)
+	if err != nil {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "the refresh token is invalid or has already been used")
+		return
+	}
+	tokenResponse(c, accessToken, refreshToken, claims.Scope, idToken(c, user, client.ClientID, claims.Scope, ""))
+}
+
+// The `clientCredentialsGrant` function issues an access token to a confidential client acting on its
+// own behalf (RFC 6749, section 4.4). No refresh token is issued, as the client can always request a new
+// access token with its credentials.
+func clientCredentialsGrant(c *gin.Context, client *models.OAuthClient) {
+	if !client.IsConfidential() {
+		oauthError(c, http.StatusBadRequest, "unauthorized_client", "public clients may not use the client credentials grant")
+		return
+	}
+
+	scope, ok := client.GrantedScope(c.PostForm("scope"))
+	if !ok {
+		oauthError(c, http.StatusBadRequest, "invalid_scope", "the requested scope is not allowed for the client")
+		return
+	}
+
+	accessToken := security.GenerateClientToken(client, scope)
+	tokenResponse(c, accessToken, "", scope, "")
+}
+
+// The `authenticateClient` function authenticates the client of a token request, either with HTTP Basic
+// authentication or with the `client_id` and `client_secret` parameters (RFC 6749, section 2.3.1). Only
+// one of the two methods may be used. It writes the error response and returns false if the client can
+// not be authenticated.
+func authenticateClient(c *gin.Context) (*models.OAuthClient, bool) {
+	clientID, secret, basic := clientCredentials(c)
+	if basic && ((c.PostForm("client_id") != "" && c.PostForm("client_id") != clientID) || c.PostForm("client_secret") != "") {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "only one client authentication method may be used")
+		return nil, false
+	}
+
+	client, err := security.AuthenticateClient(clientID, secret)
+	if errors.Is(err, security.HashingBusyError) {
+		c.Header("Retry-After", retryAfterSeconds(security.HashingRetryAfter))
+		oauthError(c, http.StatusServiceUnavailable, "temporarily_unavailable", "the server is busy, please try again later")
+		return nil, false
+	}
+	if err != nil {
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		oauthError(c, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return nil, false
+	}
+	return client, true
+}
+
+// The `clientCredentials` function returns the client ID and secret of the request and whether they
+// were sent with HTTP Basic authentication. The credentials are form encoded before they are put in the
+// header (RFC 6749, section 2.3.1).
+func clientCredentials(c *gin.Context) (string, string, bool) {
+	clientID, secret, ok := c.Request.BasicAuth()
+	if !ok {
+		return c.PostForm("client_id"), c.PostForm("client_secret"), false
+	}
+
+	if unescaped, err := url.QueryUnescape(clientID); err == nil {
+		clientID = unescaped
+	}
+	if unescaped, err := url.QueryUnescape(secret); err == nil {
+		secret = unescaped
+	}
+	return clientID, secret, true
+}
+
+// The `idToken` function returns an ID token for the user if the `openid` scope was granted, and an
+// empty string otherwise. ID tokens issued when a refresh token is used carry no nonce (OpenID Connect
+// Core 1.0, section 12.2).
+func idToken(c *gin.Context, user *models.User, clientID string, scope string, nonce string) string {
+	if !oauth.HasScope(scope, "openid") {
+		return ""
+	}
+	return security.GenerateIDToken(user, oidcIssuer(c), clientID, nonce)
+}
+
+// The `tokenResponse` function writes the successful response of the token endpoint.
+func tokenResponse(c *gin.Context, accessToken string, refreshToken string, scope string, idToken string) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, types.TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(security.TokenLifetimes.AccessToken.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        scope,
+		IDToken:      idToken,
+	})
+}
+
+// The `oauthRedirect` function redirects the user agent back to the client with the given parameters
+// added to the query of the redirect URI. Empty parameters are left out.
+func oauthRedirect(c *gin.Context, redirectURI string, params map[string]string) {
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "the redirect_uri is invalid")
+		return
+	}
+
+	query := target.Query()
+	for key, value := range params {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	target.RawQuery = query.Encode()
+	c.Redirect(http.StatusFound, target.String())
+}
+
+// The `oauthError` function writes an OAuth error response (RFC 6749, section 5.2).
+func oauthError(c *gin.Context, code int, err string, description string) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(code, types.OAuthError{
+		Error:       err,
+		Description: description,
+	})
+}
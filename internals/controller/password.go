@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/mail"
+	"coderero.dev/projects/go/gin/hello/pkg/security"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+	"coderero.dev/projects/go/gin/hello/types"
+	"github.com/gin-gonic/gin"
+)
+
+// The password reset emails of an address are throttled like the verification emails.
+const (
+	passwordResetInterval = time.Minute
+	passwordResetLimit    = 5
+)
+
+// The `ForgotPassword` function is a method of the `AuthController` struct. It sends a password reset
+// link to the given address if it belongs to an account. The response is the same whether or not there
+// is such an account, and the email is sent in the background so that the response time does not tell
+// either.
+func (AuthController) ForgotPassword(c *gin.Context) {
+	var forgot types.ForgotPassword
+
+	// The `CheckContentType` function is used to check if the content type of the request is
+	if utils.CheckContentType(c, types.Application_json) {
+		return
+	}
+	if utils.DecodeJson(c, &forgot) {
+		return
+	}
+	if err := validate.Struct(forgot); err != nil {
+		c.JSON(http.StatusBadRequest, types.Response{
+			Status: types.Status{
+				Code: http.StatusBadRequest,
+				Msg:  "validation error",
+			},
+			Errors: utils.ConvertValidationErrors(err),
+		})
+		return
+	}
+
+	key := "password_reset:" + security.LinkBinding(forgot.Email)
+	if throttled(c, key, 1, passwordResetInterval) || throttled(c, key+":daily", passwordResetLimit, 24*time.Hour) {
+		return
+	}
+
+	var user models.User
+	if err := user.GetUserByEmail(forgot.Email); err == nil {
+		token, err := security.IssuePasswordResetToken(&user)
+		if err != nil {
+			internalServerError(c)
+			return
+		}
+		link := passwordResetURL(c) + "?token=" + url.QueryEscape(token)
+		go func() {
+			if err := mail.Send(mail.PasswordResetMessage(user.Email, user.FirstName, link, security.PasswordResetLifetime)); err != nil {
+				fmt.Println(err)
+			}
+		}()
+	}
+
+	c.JSON(http.StatusAccepted, types.Response{
+		Status: types.Status{
+			Code: http.StatusAccepted,
+			Msg:  "if the address belongs to an account, a password reset link has been sent",
+		},
+	})
+}
+
+// The `ResetPassword` function is a method of the `AuthController` struct. It sets a new password with
+// the token of a password reset link. All the sessions of the user are ended, so the user has to log in
+// again with the new password.
+func (AuthController) ResetPassword(c *gin.Context) {
+	var reset types.ResetPassword
+
+	// The `CheckContentType` function is used to check if the content type of the request is
+	if utils.CheckContentType(c, types.Application_json) {
+		return
+	}
+	if utils.DecodeJson(c, &reset) {
+		return
+	}
+	if err := validate.Struct(reset); err != nil {
+		c.JSON(http.StatusBadRequest, types.Response{
+			Status: types.Status{
+				Code: http.StatusBadRequest,
+				Msg:  "validation error",
+			},
+			Errors: utils.ConvertValidationErrors(err),
+		})
+		return
+	}
+
+	if _, err := security.ResetPassword(reset.Token, reset.Password); err != nil {
+		if errors.Is(err, security.InvalidPasswordResetError) {
+			c.JSON(http.StatusBadRequest, types.Response{
+				Status: types.Status{
+					Code: http.StatusBadRequest,
+					Msg:  "the password reset link is invalid or has expired",
+				},
+			})
+			return
+		}
+		// The link is used up before the password history is checked, so the user needs a new link to
+		// choose another password.
+		if errors.Is(err, security.PasswordReusedError) {
+			passwordPolicyFailed(c, []types.APIError{reusedPasswordError("password"), {
+				Field:   "token",
+				Message: "the link has been used, request a new one to choose another password",
+			}})
+			return
+		}
+		var policyErr *security.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			passwordPolicyFailed(c, policyErr.Errors)
+			return
+		}
+		hashingFailed(c, err)
+		return
+	}
+
+	// The cookies of the browser the password was reset in belong to one of the ended sessions.
+	c.SetCookie("__t", "", -1, "/", "localhost", true, true)
+	c.SetCookie("__rt", "", -1, "/", "localhost", true, true)
+
+	c.JSON(http.StatusOK, types.Response{
+		Status: types.Status{
+			Code: http.StatusOK,
+			Msg:  "password reset, please login with the new password",
+		},
+	})
+}
+
+// The `passwordResetURL` function returns the URL of the page the password reset links open, which
+// posts the token and the new password to the reset route. It is the `PASSWORD_RESET_URL` environment
+// variable, or the `/reset-password` page of the public URL if it is not set.
+func passwordResetURL(c *gin.Context) string {
+	if url := os.Getenv("PASSWORD_RESET_URL"); url != "" {
+		return url
+	}
+	return publicURL(c) + "/reset-password"
+}
+
+// The `passwordReused` function writes the response for a new password that has been used before, with
+// the error on the given field.
+func passwordReused(c *gin.Context, field string) {
+	passwordPolicyFailed(c, []types.APIError{reusedPasswordError(field)})
+}
+
+// The `reusedPasswordError` function returns the error on the given field for a new password that has
+// been used before.
+func reusedPasswordError(field string) types.APIError {
+	return types.APIError{
+		Field:   field,
+		Message: fmt.Sprintf("must not be one of your last %d passwords", security.PasswordHistory+1),
+	}
+}
+
+// The `hashingFailed` function writes the response for an error of the password hashing helpers. A
+// server that is too busy hashing passwords answers with 503 and the `Retry-After` header, any other
+// error is an internal server error.
+func hashingFailed(c *gin.Context, err error) {
+	if !errors.Is(err, security.HashingBusyError) {
+		internalServerError(c)
+		return
+	}
+	c.Header("Retry-After", retryAfterSeconds(security.HashingRetryAfter))
+	c.JSON(http.StatusServiceUnavailable, types.Response{
+		Status: types.Status{
+			Code: http.StatusServiceUnavailable,
+			Msg:  "the server is busy, please try again later",
+		},
+	})
+}
+
+// The `weakPassword` function checks a new password against the password policy. It writes the response
+// with the broken rules and returns true if the password breaks any of them or can not be checked.
+func weakPassword(c *gin.Context, field string, password string, username string, email string) bool {
+	errs, err := security.CheckPasswordPolicy(field, password, username, email)
+	if err != nil {
+		internalServerError(c)
+		return true
+	}
+	if len(errs) == 0 {
+		return false
+	}
+	passwordPolicyFailed(c, errs)
+	return true
+}
+
+// The `passwordPolicyFailed` function writes the response for a new password that breaks the password
+// policy, with one error for every broken rule.
+func passwordPolicyFailed(c *gin.Context, errs []types.APIError) {
+	c.JSON(http.StatusBadRequest, types.Response{
+		Status: types.Status{
+			Code: http.StatusBadRequest,
+			Msg:  "validation error",
+		},
+		Errors: errs,
+	})
+}
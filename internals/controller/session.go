@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"net/http"
+
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/security"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+	"coderero.dev/projects/go/gin/hello/types"
+	"github.com/gin-gonic/gin"
+)
+
+type SessionController struct{}
+
+// The `List` function is a method of the `SessionController` struct. It returns all the sessions of the
+// logged in user and marks the session the request was made with.
+func (SessionController) List(c *gin.Context) {
+	user, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	// The sessions whose refresh token family has expired are deleted before the sessions are listed.
+	var session models.Session
+	if err := session.DeleteExpiredSessions(user.ID, security.TokenLifetimes.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, types.Response{
+			Status: types.Status{
+				Code: http.StatusInternalServerError,
+				Msg:  "something went wrong",
+			},
+		})
+		return
+	}
+	sessions := session.GetUserSessions(user.ID)
+	current := c.GetString(types.ContextSessionID)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+
+	c.JSON(http.StatusOK, types.Response{
+		Status: types.Status{
+			Code: http.StatusOK,
+			Msg:  "ok",
+		},
+		Data: sessions,
+	})
+}
+
+// The `Revoke` function is a method of the `SessionController` struct. It ends the session with the ID
+// given in the path, which has to belong to the logged in user.
+func (SessionController) Revoke(c *gin.Context) {
+	user, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	var session models.Session
+	if err := session.GetSession(c.Param("id"), user.ID); err != nil {
+		c.JSON(http.StatusNotFound, types.Response{
+			Status: types.Status{
+				Code: http.StatusNotFound,
+				Msg:  "session not found",
+			},
+		})
+		return
+	}
+
+	if err := security.RevokeSession(&session); err != nil {
+		c.JSON(http.StatusInternalServerError, types.Response{
+			Status: types.Status{
+				Code: http.StatusInternalServerError,
+				Msg:  "something went wrong",
+			},
+		})
+		return
+	}
+
+	// The cookies are removed when the user ends the session the request was made with.
+	if session.ID == c.GetString(types.ContextSessionID) {
+		c.SetCookie("__t", "", -1, "/", "localhost", true, true)
+		c.SetCookie("__rt", "", -1, "/", "localhost", true, true)
+	}
+
+	c.JSON(http.StatusOK, types.Response{
+		Status: types.Status{
+			Code: http.StatusOK,
+			Msg:  "session revoked",
+		},
+	})
+}
+
+// The `RevokeOthers` function is a method of the `SessionController` struct. It ends all the sessions
+// of the logged in user except the session the request was made with.
+func (SessionController) RevokeOthers(c *gin.Context) {
+	user, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	var session models.Session
+	sessions, err := session.DeleteOtherSessions(user.ID, c.GetString(types.ContextSessionID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, types.Response{
+			Status: types.Status{
+				Code: http.StatusInternalServerError,
+				Msg:  "something went wrong",
+			},
+		})
+		return
+	}
+	for i := range sessions {
+		security.RevokeSession(&sessions[i])
+	}
+
+	c.JSON(http.StatusOK, types.Response{
+		Status: types.Status{
+			Code: http.StatusOK,
+			Msg:  "sessions revoked",
+		},
+		Data: map[string]any{
+			"revoked": len(sessions),
+		},
+	})
+}
+
+// The `newSession` function creates a new session for the user from the request the user logged in
+// with. The expired sessions of the user are deleted on the way.
+func newSession(c *gin.Context, user *models.User) (*models.Session, error) {
+	id, err := utils.RandomID(16)
+	if err != nil {
+		return nil, err
+	}
+
+	var expired models.Session
+	expired.DeleteExpiredSessions(user.ID, security.TokenLifetimes.RefreshToken)
+
+	session := &models.Session{
+		ID:        id,
+		UserID:    user.ID,
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+	return session.Create(), nil
+}
+
+// The `sessionUser` function returns the user the authentication middleware has authenticated. It
+// writes the response and returns false if the user does not exist.
+func sessionUser(c *gin.Context) (*models.User, bool) {
+	user, err := security.GetSubjectUser(c.GetString(types.ContextSubject))
+	if err != nil {
+		c.JSON(http.StatusNotFound, types.Response{
+			Status: types.Status{
+				Code: http.StatusNotFound,
+				Msg:  "user not found",
+			},
+		})
+		return nil, false
+	}
+	return user, true
+}
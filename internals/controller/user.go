@@ -19,7 +19,7 @@ type UpdateUser struct {
 	Username    string `json:"username,omitempty" validate:"omitempty,min=3,max=32,alphanum"`
 	Email       string `json:"email,omitempty" validate:"omitempty,email"`
 	Password    string `json:"password,omitempty" validate:"required,min=8"`
-	NewPassword string `json:"new_password,omitempty" validate:"omitempty,min=8"`
+	NewPassword string `json:"new_password,omitempty"`
 	FirstName   string `json:"firstname,omitempty" validate:"omitempty,alpha"`
 	LastName    string `json:"lastname,omitempty" validate:"omitempty,alpha"`
 	Age         int    `json:"age,omitempty" validate:"omitempty,gt=0,lt=100"`
@@ -33,7 +33,7 @@ func (u *UserController) Get(c *gin.Context) {
 		return
 	}
 
-	email, err := extractEmailFromToken(accessToken)
+	sub, err := extractSubjectFromToken(accessToken)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, types.Response{
 			Status: types.Status{
@@ -44,8 +44,8 @@ func (u *UserController) Get(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := user.GetUserByEmail(email); err != nil {
+	user, err := security.GetSubjectUser(sub)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, types.Response{
 			Status: types.Status{
 				Code: http.StatusBadRequest,
@@ -74,7 +74,7 @@ func (u *UserController) Update(c *gin.Context) {
 		return
 	}
 
-	email, err := extractEmailFromToken(accessToken)
+	sub, err := extractSubjectFromToken(accessToken)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, types.Response{
 			Status: types.Status{
@@ -85,8 +85,8 @@ func (u *UserController) Update(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := user.GetUserByEmail(email); err != nil {
+	user, err := security.GetSubjectUser(sub)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, types.Response{
 			Status: types.Status{
 				Code: http.StatusBadRequest,
@@ -122,7 +122,12 @@ func (u *UserController) Update(c *gin.Context) {
 		return
 	}
 
-	if !security.ComparePassword(update.Password, user.Password) {
+	match, err := security.ComparePassword(update.Password, user.Password)
+	if err != nil {
+		hashingFailed(c, err)
+		return
+	}
+	if !match {
 		c.JSON(http.StatusBadRequest, types.Response{
 			Status: types.Status{
 				Code: http.StatusBadRequest,
@@ -158,8 +163,39 @@ func (u *UserController) Update(c *gin.Context) {
 		}
 	}
 
+	// The new password has to follow the password policy, checked against the new username and email
+	// address if they are changed as well, and must not be the current password or one of the previous
+	// ones.
+	if update.NewPassword != "" {
+		username, email := user.Username, user.Email
+		if update.Username != "" {
+			username = update.Username
+		}
+		if update.Email != "" {
+			email = update.Email
+		}
+		if weakPassword(c, "new_password", update.NewPassword, username, email) {
+			return
+		}
+	}
+	if update.NewPassword != "" {
+		reused, err := security.IsPasswordReused(user, update.NewPassword)
+		if err != nil {
+			hashingFailed(c, err)
+			return
+		}
+		if reused {
+			passwordReused(c, "new_password")
+			return
+		}
+	}
+
 	if update.NewPassword != "" {
 		update.NewPassword, err = security.HashPassword(update.NewPassword)
+		if errors.Is(err, security.HashingBusyError) {
+			hashingFailed(c, err)
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusBadRequest, types.Response{
 				Status: types.Status{
@@ -171,10 +207,37 @@ func (u *UserController) Update(c *gin.Context) {
 		}
 	}
 
+	// A new email address has to be verified again, so it is stored together with the cleared verified
+	// flag.
+	emailChanged := update.Email != "" && update.Email != user.Email
+	if emailChanged {
+		if err := user.ChangeEmail(update.Email); err != nil {
+			c.JSON(http.StatusInternalServerError, types.Response{
+				Status: types.Status{
+					Code: http.StatusInternalServerError,
+					Msg:  "something went wrong",
+				},
+			})
+			return
+		}
+	}
+
+	// The new password is stored together with the previous password in the password history of the
+	// user.
+	if update.NewPassword != "" {
+		if err := security.ChangePassword(user, update.NewPassword); err != nil {
+			c.JSON(http.StatusInternalServerError, types.Response{
+				Status: types.Status{
+					Code: http.StatusInternalServerError,
+					Msg:  "something went wrong",
+				},
+			})
+			return
+		}
+	}
+
 	updateUser := models.User{
 		Username:  update.Username,
-		Email:     update.Email,
-		Password:  update.NewPassword,
 		FirstName: update.FirstName,
 		LastName:  update.LastName,
 		Age:       update.Age,
@@ -190,6 +253,11 @@ func (u *UserController) Update(c *gin.Context) {
 		return
 	}
 
+	if emailChanged {
+		sendVerificationEmail(c, user)
+	}
+	updateUser.Email = update.Email
+
 	c.JSON(http.StatusOK, types.Response{
 		Status: types.Status{
 			Code: http.StatusOK,
@@ -207,7 +275,7 @@ func (u *UserController) Delete(c *gin.Context) {
 		return
 	}
 
-	email, err := extractEmailFromToken(accessToken)
+	sub, err := extractSubjectFromToken(accessToken)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, types.Response{
 			Status: types.Status{
@@ -218,8 +286,8 @@ func (u *UserController) Delete(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := user.GetUserByEmail(email); err != nil {
+	user, err := security.GetSubjectUser(sub)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, types.Response{
 			Status: types.Status{
 				Code: http.StatusBadRequest,
@@ -255,7 +323,8 @@ func (u *UserController) Delete(c *gin.Context) {
 	})
 }
 
-func extractEmailFromToken(token string) (string, error) {
+// The function `extractSubjectFromToken` verifies a token and returns its subject.
+func extractSubjectFromToken(token string) (string, error) {
 	jwtToken, err := security.VerifyToken(token)
 
 	if err != nil {
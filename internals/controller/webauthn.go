@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"coderero.dev/projects/go/gin/hello/pkg/security"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+	"coderero.dev/projects/go/gin/hello/pkg/webauthn"
+	"coderero.dev/projects/go/gin/hello/types"
+	"github.com/gin-gonic/gin"
+)
+
+type WebAuthnController struct{}
+
+// The `RegisterBegin` function is a method of the `WebAuthnController` struct. It starts the
+// registration of a passkey for the logged in user and returns the options the browser passes to
+// `navigator.credentials.create()`.
+func (WebAuthnController) RegisterBegin(c *gin.Context) {
+	user, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	options, err := security.BeginWebAuthnRegistration(user)
+	if err != nil {
+		internalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, types.Response{
+		Status: types.Status{
+			Code: http.StatusOK,
+			Msg:  "ok",
+		},
+		Data: map[string]any{
+			"publicKey": options,
+		},
+	})
+}
+
+// The `RegisterFinish` function is a method of the `WebAuthnController` struct. It verifies the
+// credential created by the authenticator and registers it for the logged in user. The name of the
+// credential, shown to the user later, is given in the `name` query parameter.
+func (WebAuthnController) RegisterFinish(c *gin.Context) {
+	var response webauthn.AttestationResponse
+
+	// The `CheckContentType` function is used to check if the content type of the request is
+	if utils.CheckContentType(c, types.Application_json) {
+		return
+	}
+	if utils.DecodeJson(c, &response) {
+		return
+	}
+
+	user, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	credential, err := security.FinishWebAuthnRegistration(user, response, c.Query("name"))
+	if errors.Is(err, security.WebAuthnCredentialExistsError) {
+		c.JSON(http.StatusConflict, types.Response{
+			Status: types.Status{
+				Code: http.StatusConflict,
+				Msg:  "the passkey is already registered",
+			},
+		})
+		return
+	}
+	if err != nil {
+		webAuthnFailed(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, types.Response{
+		Status: types.Status{
+			Code: http.StatusCreated,
+			Msg:  "passkey registered",
+		},
+		Data: credential,
+	})
+}
+
+// The `LoginBegin` function is a method of the `WebAuthnController` struct. It starts a passwordless
+// login and returns the options the browser passes to `navigator.credentials.get()`.
+func (WebAuthnController) LoginBegin(c *gin.Context) {
+	options, err := security.BeginWebAuthnLogin()
+	if err != nil {
+		internalServerError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, types.Response{
+		Status: types.Status{
+			Code: http.StatusOK,
+			Msg:  "ok",
+		},
+		Data: map[string]any{
+			"publicKey": options,
+		},
+	})
+}
+
+// The `LoginFinish` function is a method of the `WebAuthnController` struct. It verifies the assertion
+// of the authenticator and logs in the user the passkey belongs to, the same way `Login` does.
+func (WebAuthnController) LoginFinish(c *gin.Context) {
+	var response webauthn.AssertionResponse
+
+	// The `CheckContentType` function is used to check if the content type of the request is
+	if utils.CheckContentType(c, types.Application_json) {
+		return
+	}
+
+	// The `previousTokens` function is used to check if the access token and refresh token are present in
+	// the request header or cookies. If they are present, they are revoked.
+	previousTokens(c)
+
+	if utils.DecodeJson(c, &response) {
+		return
+	}
+
+	user, err := security.FinishWebAuthnLogin(response)
+	if err != nil {
+		webAuthnFailed(c, err)
+		return
+	}
+	if emailNotVerified(c, user) {
+		return
+	}
+
+	loginSuccessful(c, user, c.Query("return_token"))
+}
+
+// The `webAuthnFailed` function returns a JSON response indicating that a WebAuthn ceremony could not
+// be completed.
+func webAuthnFailed(c *gin.Context, err error) {
+	c.JSON(http.StatusUnauthorized, types.Response{
+		Status: types.Status{
+			Code: http.StatusUnauthorized,
+			Msg:  "passkey verification failed",
+		},
+		Errors: []types.APIError{
+			{
+				Field:   "credential",
+				Message: err.Error(),
+			},
+		},
+	})
+}
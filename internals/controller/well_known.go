@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"coderero.dev/projects/go/gin/hello/pkg/security"
+	"coderero.dev/projects/go/gin/hello/types"
+	"github.com/gin-gonic/gin"
+)
+
+type WellKnownController struct{}
+
+// The `JWKS` function is a method of the `WellKnownController` struct. It is used as a handler function
+// for the `/.well-known/jwks.json` route, which publishes the public keys other services verify the
+// tokens of this service with. The document is not wrapped in a `types.Response`, because its format is
+// defined by RFC 7517.
+func (WellKnownController) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, security.JWKS())
+}
+
+// The `OpenIDConfiguration` function is a method of the `WellKnownController` struct. It is used as a
+// handler function for the `/.well-known/openid-configuration` route, which lets other applications
+// discover the endpoints and capabilities of this service as an OpenID Connect provider.
+func (WellKnownController) OpenIDConfiguration(c *gin.Context) {
+	issuer := oidcIssuer(c)
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, types.OpenIDConfiguration{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/api/v1/oauth/authorize",
+		TokenEndpoint:                     issuer + "/api/v1/oauth/token",
+		UserInfoEndpoint:                  issuer + "/api/v1/oauth/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		IntrospectionEndpoint:             issuer + "/api/v1/oauth/introspect",
+		RevocationEndpoint:                issuer + "/api/v1/oauth/revoke",
+		ScopesSupported:                   []string{"openid", "profile", "email"},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "refresh_token", "client_credentials"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{security.SigningAlgorithm()},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "nonce", "preferred_username", "name", "given_name", "family_name", "email"},
+	})
+}
+
+// The `oidcIssuer` function returns the issuer identifier of the OpenID Connect provider, which is the
+// URL the discovery document is served under. It is the configured `JWT_ISSUER`, or the URL of the
+// request if no issuer is configured.
+func oidcIssuer(c *gin.Context) string {
+	if issuer := security.Issuer(); issuer != "" {
+		return strings.TrimSuffix(issuer, "/")
+	}
+	return requestURL(c)
+}
+
+// The `publicURL` function returns the URL of the service that is put in the links sent by email. It is
+// the `APP_URL` environment variable, or the URL of the request if it is not set.
+func publicURL(c *gin.Context) string {
+	if url := os.Getenv("APP_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return requestURL(c)
+}
+
+// The `requestURL` function returns the scheme and the host the request was made to.
+func requestURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
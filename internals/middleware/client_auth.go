@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/security"
+	types "coderero.dev/projects/go/gin/hello/types"
+	"github.com/gin-gonic/gin"
+)
+
+// The ClientAuthMiddleWare function is a middleware that authenticates the OAuth client calling a
+// service endpoint, either with its client ID and secret (HTTP Basic authentication) or with an access
+// token it was issued with the client credentials grant. Requests with the access token of a user are
+// handed to the `JWTAuthMiddleWare`, so that a logged in user can still call the endpoint.
+func ClientAuthMiddleWare() gin.HandlerFunc {
+	userAuth := JWTAuthMiddleWare()
+	return func(c *gin.Context) {
+		if clientID, secret, ok := c.Request.BasicAuth(); ok {
+			if unescaped, err := url.QueryUnescape(clientID); err == nil {
+				clientID = unescaped
+			}
+			if unescaped, err := url.QueryUnescape(secret); err == nil {
+				secret = unescaped
+			}
+
+			client, err := security.AuthenticateClient(clientID, secret)
+			if errors.Is(err, security.HashingBusyError) {
+				c.Header("Retry-After", strconv.Itoa(int(security.HashingRetryAfter.Seconds())))
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, types.Response{
+					Status: types.Status{
+						Code: http.StatusServiceUnavailable,
+						Msg:  "the server is busy, please try again later",
+					},
+				})
+				return
+			}
+			if err != nil || !client.IsConfidential() {
+				c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+				invalidClient(c)
+				return
+			}
+			c.Set(types.ContextClientID, client.ClientID)
+			c.Next()
+			return
+		}
+
+		token, found := strings.CutPrefix(c.Request.Header.Get("Authorization"), "Bearer ")
+		if !found {
+			userAuth(c)
+			return
+		}
+		jwtToken, err := security.VerifyToken(token)
+		if err != nil || !security.IsClientToken(jwtToken.Claims.(*security.Claims)) {
+			userAuth(c)
+			return
+		}
+
+		claims, active := security.IntrospectToken(token)
+		var client models.OAuthClient
+		if !active || client.GetClient(claims.ClientID) != nil {
+			InvalidToken(c)
+			return
+		}
+		c.Set(types.ContextClientID, client.ClientID)
+		c.Next()
+	}
+}
+
+// The invalidClient function returns a JSON response indicating that the client could not be
+// authenticated and aborts the current request.
+func invalidClient(c *gin.Context) {
+	c.JSON(http.StatusUnauthorized, types.Response{
+		Status: types.Status{
+			Code: http.StatusUnauthorized,
+			Msg:  "invalid client",
+		},
+	})
+	c.Abort()
+}
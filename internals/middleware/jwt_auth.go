@@ -12,8 +12,22 @@ import (
 )
 
 // The JWTAuthMiddleWare function is a middleware that handles authentication using JSON Web Tokens
-// (JWT) in a Go web application.
+// (JWT) in a Go web application. It only accepts the first-party tokens of a login, because the access
+// tokens issued to OAuth clients are limited to the scopes the user has consented to.
 func JWTAuthMiddleWare() gin.HandlerFunc {
+	return jwtAuth(false)
+}
+
+// The OAuthMiddleWare function is a middleware that authenticates a user like the `JWTAuthMiddleWare`,
+// but also accepts the access tokens issued to OAuth clients on behalf of a user. It is only used for
+// the OAuth endpoints that check the scope of the token themselves.
+func OAuthMiddleWare() gin.HandlerFunc {
+	return jwtAuth(true)
+}
+
+// The jwtAuth function returns the middleware behind the `JWTAuthMiddleWare` and the `OAuthMiddleWare`.
+// The access tokens issued to OAuth clients are only accepted if `allowClients` is set.
+func jwtAuth(allowClients bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		// `token := c.Request.Header.Get("Authorization")` is retrieving the value of the "Authorization"
@@ -48,6 +62,10 @@ func JWTAuthMiddleWare() gin.HandlerFunc {
 			}
 
 			jwtToken, err := security.VerifyToken(typeOfToken[1])
+			if err != nil {
+				InvalidToken(c)
+				return
+			}
 
 			sub, subErr := jwtToken.Claims.GetSubject()
 			if subErr != nil {
@@ -57,19 +75,25 @@ func JWTAuthMiddleWare() gin.HandlerFunc {
 						Msg:  "unauthorized",
 					},
 				})
+				c.Abort()
 				return
 			}
 
-			if err != nil {
+			// A refresh token is only exchanged for new tokens and does not authenticate a request, and a
+			// token an OAuth client was issued for itself does not identify a user.
+			if !isAccessToken(jwtToken.Claims.(*security.Claims), allowClients) {
 				InvalidToken(c)
 				return
 			}
-			shouldReturn := checkUser(sub, c)
+			_, shouldReturn := checkUser(sub, c)
 			if shouldReturn {
 				return
 			}
-			// Check if the token is valid
+			// Check if the token is valid and if its session has not been revoked
 			if jwtToken.Valid {
+				if checkSession(jwtToken.Claims.(*security.Claims), c) {
+					return
+				}
 				c.Next()
 				return
 			} else {
@@ -131,13 +155,23 @@ func JWTAuthMiddleWare() gin.HandlerFunc {
 		// The code block is checking if the access token is not expired. If the access token is not expired,
 		// it calls the `c.Next()` function to pass the request to the next middleware function.
 		if !security.IsTokenExpired(accessToken) && !cache.IsTokenRevoked(accessToken) {
+			jwtToken, err := security.VerifyToken(accessToken)
+			if err != nil || !isAccessToken(jwtToken.Claims.(*security.Claims), allowClients) {
+				InvalidToken(c)
+				return
+			}
+			if checkSession(jwtToken.Claims.(*security.Claims), c) {
+				return
+			}
 			c.Next()
 			return
 		}
 
-		// If the access token is expired but the refresh token is not expired, generate a new access token and set it as a cookie
+		// If the access token is expired but the refresh token is not expired, the refresh token is
+		// rotated like in the `RefreshToken` function of the `AuthController` and both new tokens are set
+		// as cookies.
 		if security.IsTokenExpired(accessToken) && !security.IsTokenExpired(refreshToken) {
-			// Get Subject from the access token
+			// Get Subject from the refresh token
 			claims, err := security.VerifyToken(refreshToken)
 			if err != nil {
 				c.JSON(http.StatusUnauthorized, types.Response{
@@ -161,12 +195,30 @@ func JWTAuthMiddleWare() gin.HandlerFunc {
 				c.Abort()
 				return
 			}
-			shouldReturn := checkUser(subject, c)
+			user, shouldReturn := checkUser(subject, c)
 			if shouldReturn {
 				return
 			}
-			newAccessToken := security.GenerateToken(subject, security.AcessTokenExpireTime)
-			c.SetCookie("__t", newAccessToken, 3600, "/", "localhost", false, true)
+			refreshClaims := claims.Claims.(*security.Claims)
+			if !allowClients && refreshClaims.ClientID != "" {
+				InvalidToken(c)
+				return
+			}
+			if checkSession(refreshClaims, c) {
+				return
+			}
+
+			// A refresh token that has already been rotated revokes its whole family, so that neither the
+			// attacker nor the legitimate browser can keep using it.
+			newAccessToken, newRefreshToken, err := security.RotateRefreshToken(refreshToken, user.UUID)
+			if err != nil {
+				c.SetCookie("__t", "", -1, "/", "localhost", true, true)
+				c.SetCookie("__rt", "", -1, "/", "localhost", true, true)
+				InvalidToken(c)
+				return
+			}
+			c.SetCookie("__t", newAccessToken, security.TokenLifetimes.AccessTokenMaxAge(), "/", "localhost", true, true)
+			c.SetCookie("__rt", newRefreshToken, security.TokenLifetimes.RefreshTokenMaxAge(), "/", "localhost", true, true)
 			c.Next()
 			return
 		}
@@ -187,9 +239,10 @@ func JWTAuthMiddleWare() gin.HandlerFunc {
 
 }
 
-func checkUser(sub string, c *gin.Context) bool {
-	var user *models.User
-	userErr := user.GetUserByEmail(sub)
+// The function `checkUser` returns the user the subject of a token identifies. If the user does not
+// exist, the tokens of the request are revoked and removed.
+func checkUser(sub string, c *gin.Context) (*models.User, bool) {
+	user, userErr := security.GetSubjectUser(sub)
 	if userErr != nil {
 		c.JSON(http.StatusNotFound, types.Response{
 			Status: types.Status{
@@ -204,11 +257,47 @@ func checkUser(sub string, c *gin.Context) bool {
 		}
 		c.SetCookie("__t", "", -1, "/", "localhost", true, true)
 		c.SetCookie("__rt", "", -1, "/", "localhost", true, true)
+		return nil, true
+	}
+	return user, false
+}
+
+// The function `checkSession` checks if the session a token was issued for still exists and records
+// its activity. It stores the subject, the scope and the session of the token in the context for the
+// handlers. Tokens issued before sessions existed do not carry a session and are only rejected if all
+// the tokens of the user have been revoked since.
+func checkSession(claims *security.Claims, c *gin.Context) bool {
+	if security.IsTokenRevokedForUser(claims) {
+		InvalidToken(c)
 		return true
 	}
+
+	c.Set(types.ContextSubject, claims.Subject)
+	c.Set(types.ContextScope, claims.Scope)
+	if claims.Session == "" {
+		return false
+	}
+
+	var session models.Session
+	if err := session.Touch(claims.Session, security.TokenLifetimes.RefreshToken); err != nil {
+		InvalidToken(c)
+		return true
+	}
+	c.Set(types.ContextSessionID, claims.Session)
 	return false
 }
 
+// The function `isAccessToken` checks if a token may authenticate a request on behalf of a user. Refresh
+// tokens carry the family they belong to, and the tokens OAuth clients are issued for themselves do not
+// identify a user. The tokens issued to OAuth clients on behalf of a user are only accepted if
+// `allowClients` is set.
+func isAccessToken(claims *security.Claims, allowClients bool) bool {
+	if claims.Family != "" || security.IsClientToken(claims) {
+		return false
+	}
+	return allowClients || claims.ClientID == ""
+}
+
 // The function checks if a token has been revoked based on the provided access token and refresh
 // token.
 func checkTokenRevoketion(accessToken string, refreshToken string, c *gin.Context) bool {
@@ -18,5 +18,12 @@ func authRouter(group *gin.RouterGroup) {
 		group.POST("/logout", auth.Logout)
 		group.POST("/refresh", auth.RefreshToken)
 		group.GET("/logged-in", auth.IsLoggedIn)
+		group.GET("/auth/verify-email", auth.VerifyEmail)
+		group.POST("/auth/verify-email/resend", auth.ResendVerification)
+		group.POST("/auth/password/forgot", auth.ForgotPassword)
+		group.POST("/auth/password/reset", auth.ResetPassword)
+		group.POST("/auth/magic-link", auth.RequestMagicLink)
+		group.GET("/auth/magic-link/consume", auth.ConsumeMagicLink)
+		group.GET("/auth/unlock", auth.UnlockAccount)
 	}
 }
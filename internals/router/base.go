@@ -40,15 +40,25 @@ func Router() *gin.Engine {
 
 	fmt.Println(strings.Split(os.Getenv("ALLOWED_ORIGINS"), ","))
 
+	// Well-known documents are served outside of the API group
+	wellKnownRouter(r.Group("/.well-known"))
+
 	// Sub-Routers
 	sub := r.Group("/api/v1")
 
+	// Routes that are not protected by the CSRF check
+	oauthRouter(sub)
+
 	// Add Global Middlewares
 	sub.Use(middleware.CsrfCheck())
 
 	// Route Handlers
 	authRouter(sub)
 	csrfRouter(sub)
+	oauthConsentRouter(sub)
+	sessionRouter(sub)
+	mfaRouter(sub)
+	webAuthnRouter(sub)
 	appRouter(sub)
 	userRouter(sub)
 
@@ -0,0 +1,26 @@
+package router
+
+import (
+	"coderero.dev/projects/go/gin/hello/internals/controller"
+	"coderero.dev/projects/go/gin/hello/internals/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// The function mfaRouter is used to register routes for the two-factor authentication group.
+func mfaRouter(group *gin.RouterGroup) {
+	// The MFA group is registered before `appRouter` adds the `JWTAuthMiddleWare` to the whole group,
+	// because the verify route is called with the MFA challenge before the user has any tokens. The
+	// enrollment routes register the middleware themselves.
+	mfaGroup := group.Group("/auth/mfa")
+	mfa := new(controller.MFAController)
+
+	// The following code block registers MFA routes.
+	{
+		mfaGroup.POST("/verify", mfa.Verify)
+		mfaGroup.POST("/totp", middleware.JWTAuthMiddleWare(), mfa.EnrollTOTP)
+		mfaGroup.POST("/totp/confirm", middleware.JWTAuthMiddleWare(), mfa.ConfirmTOTP)
+		mfaGroup.DELETE("/totp", middleware.JWTAuthMiddleWare(), mfa.DisableTOTP)
+		mfaGroup.GET("/recovery-codes", middleware.JWTAuthMiddleWare(), mfa.RecoveryCodes)
+		mfaGroup.POST("/recovery-codes", middleware.JWTAuthMiddleWare(), mfa.RegenerateRecoveryCodes)
+	}
+}
@@ -0,0 +1,47 @@
+package router
+
+import (
+	"coderero.dev/projects/go/gin/hello/internals/controller"
+	"coderero.dev/projects/go/gin/hello/internals/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// The function oauthRouter is used to register routes for the OAuth group. The OAuth endpoints are
+// called by other services and clients with tokens instead of cookies, so the group is registered
+// before the CSRF check is added to the API group.
+func oauthRouter(group *gin.RouterGroup) {
+	oauthGroup := group.Group("/oauth")
+
+	// `oauth := new(controller.OAuthController)` is creating a new instance of the `OAuthController`
+	// struct.
+	oauth := new(controller.OAuthController)
+
+	// The following code block registers OAuth routes. The authorization endpoint requires the user to
+	// be logged in, the userinfo endpoint requires an access token with the openid scope, which only the
+	// tokens issued to OAuth clients carry, and the introspection endpoint requires an authenticated
+	// client or user. The token endpoint authenticates the client itself, and the revocation endpoint
+	// identifies the client by the token it is given, so that a client can still use its refresh token
+	// after its access token has expired.
+	{
+		oauthGroup.GET("/authorize", middleware.JWTAuthMiddleWare(), oauth.Authorize)
+		oauthGroup.POST("/token", oauth.Token)
+		oauthGroup.POST("/introspect", middleware.ClientAuthMiddleWare(), oauth.Introspect)
+		oauthGroup.POST("/revoke", oauth.Revoke)
+		oauthGroup.GET("/userinfo", middleware.OAuthMiddleWare(), oauth.UserInfo)
+		oauthGroup.POST("/userinfo", middleware.OAuthMiddleWare(), oauth.UserInfo)
+	}
+}
+
+// The function oauthConsentRouter is used to register the consent route of the OAuth group. Unlike the
+// other OAuth endpoints it is called by the browser of the logged in user, so it is registered after the
+// CSRF check has been added to the API group.
+func oauthConsentRouter(group *gin.RouterGroup) {
+	oauthGroup := group.Group("/oauth")
+	oauthGroup.Use(middleware.JWTAuthMiddleWare())
+	oauth := new(controller.OAuthController)
+
+	// The following code block registers the consent route.
+	{
+		oauthGroup.POST("/consent", oauth.Consent)
+	}
+}
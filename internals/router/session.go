@@ -0,0 +1,23 @@
+package router
+
+import (
+	"coderero.dev/projects/go/gin/hello/internals/controller"
+	"coderero.dev/projects/go/gin/hello/internals/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// The function sessionRouter is used to register routes for the sessions group.
+func sessionRouter(group *gin.RouterGroup) {
+	// The sessions group is registered before `appRouter` adds the `JWTAuthMiddleWare` to the whole
+	// group, so it registers the middleware on its own sub-group.
+	sessions := group.Group("/sessions")
+	sessions.Use(middleware.JWTAuthMiddleWare())
+	session := new(controller.SessionController)
+
+	// The following code block registers session routes.
+	{
+		sessions.GET("", session.List)
+		sessions.DELETE("", session.RevokeOthers)
+		sessions.DELETE("/:id", session.Revoke)
+	}
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"coderero.dev/projects/go/gin/hello/internals/controller"
+	"coderero.dev/projects/go/gin/hello/internals/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// The function webAuthnRouter is used to register routes for the WebAuthn (passkey) group.
+func webAuthnRouter(group *gin.RouterGroup) {
+	// The WebAuthn group is registered before `appRouter` adds the `JWTAuthMiddleWare` to the whole
+	// group, because the login routes are called before the user has any tokens. The registration routes
+	// register the middleware themselves.
+	webAuthnGroup := group.Group("/auth/webauthn")
+	webAuthn := new(controller.WebAuthnController)
+
+	// The following code block registers WebAuthn routes.
+	{
+		webAuthnGroup.POST("/register/begin", middleware.JWTAuthMiddleWare(), webAuthn.RegisterBegin)
+		webAuthnGroup.POST("/register/finish", middleware.JWTAuthMiddleWare(), webAuthn.RegisterFinish)
+		webAuthnGroup.POST("/login/begin", webAuthn.LoginBegin)
+		webAuthnGroup.POST("/login/finish", webAuthn.LoginFinish)
+	}
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"coderero.dev/projects/go/gin/hello/internals/controller"
+	"github.com/gin-gonic/gin"
+)
+
+// The function `wellKnownRouter` sets up the routes of the `/.well-known` group. These routes are
+// public and are not protected by CSRF checks, because they are fetched by other services.
+func wellKnownRouter(group *gin.RouterGroup) {
+	// `wellKnown := new(controller.WellKnownController)` is creating a new instance of the
+	// `WellKnownController` struct.
+	wellKnown := new(controller.WellKnownController)
+
+	// The following code block registers the well-known routes.
+	{
+		group.GET("/jwks.json", wellKnown.JWKS)
+		group.GET("/openid-configuration", wellKnown.OpenIDConfiguration)
+	}
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/pkg/oauth"
+)
+
+// The OAuthClient struct defines the structure of a registered OAuth client in the database. The
+// `RedirectURIs`, `Scopes` and `GrantTypes` fields hold the space separated redirect URIs, scopes and
+// grant types the client may use; redirect URIs are compared exactly. The `Secret` field holds the hash
+// of the client secret and is empty for public clients, which can not keep a secret. The users of a
+// `FirstParty` client, which is operated by the deployment itself, are not asked for their consent.
+type OAuthClient struct {
+	ID           uint      `json:"-" gorm:"primarykey"`
+	ClientID     string    `json:"client_id" gorm:"uniqueIndex;not null"`
+	Secret       string    `json:"-"`
+	Name         string    `json:"name" gorm:"not null"`
+	RedirectURIs string    `json:"redirect_uris" gorm:"not null"`
+	Scopes       string    `json:"scopes" gorm:"not null;default:''"`
+	GrantTypes   string    `json:"grant_types" gorm:"not null;default:'authorization_code refresh_token'"`
+	FirstParty   bool      `json:"first_party" gorm:"not null;default:false"`
+	CreatedAt    time.Time `json:"-"`
+	UpdatedAt    time.Time `json:"-"`
+}
+
+// The `TableName` method names the table of the `OAuthClient` model.
+func (OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+// The `Create()` method is used to create a new OAuth client record in the database.
+func (o *OAuthClient) Create() (*OAuthClient, error) {
+	if err := db.Model(&o).Create(&o).Error; err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// The `GetClient` method is used to retrieve an OAuth client record from the database based on the
+// provided client ID.
+func (o *OAuthClient) GetClient(clientID string) error {
+	return db.Model(&o).Where("client_id = ?", clientID).First(&o).Error
+}
+
+// The `RedirectURI` method returns the redirect URI of the client that matches the requested one. If no
+// redirect URI is requested, the registered one is used, provided the client has registered only one.
+func (o *OAuthClient) RedirectURI(requested string) (string, bool) {
+	uris := strings.Fields(o.RedirectURIs)
+	if requested == "" {
+		if len(uris) == 1 {
+			return uris[0], true
+		}
+		return "", false
+	}
+
+	for _, uri := range uris {
+		if uri == requested {
+			return uri, true
+		}
+	}
+	return "", false
+}
+
+// The `IsConfidential` method reports if the client has a secret it has to authenticate with.
+func (o *OAuthClient) IsConfidential() bool {
+	return o.Secret != ""
+}
+
+// The `AllowsGrantType` method reports if the client may use the given grant type.
+func (o *OAuthClient) AllowsGrantType(grantType string) bool {
+	for _, allowed := range strings.Fields(o.GrantTypes) {
+		if allowed == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// The `GrantedScope` method returns the scope the client is granted for the requested scope. Every
+// requested scope has to be allowed for the client; if no scope is requested, all the allowed scopes are
+// granted.
+func (o *OAuthClient) GrantedScope(requested string) (string, bool) {
+	return oauth.GrantedScope(o.Scopes, requested)
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/pkg/oauth"
+	"gorm.io/gorm/clause"
+)
+
+// The OAuthConsent struct defines the structure of the consent a user has given an OAuth client in the
+// database. The `Scope` field holds the space separated scopes the user has agreed to share with the
+// client.
+type OAuthConsent struct {
+	ID        uint      `json:"-" gorm:"primarykey"`
+	UserID    uint      `json:"-" gorm:"not null;uniqueIndex:idx_oauth_consent"`
+	ClientID  string    `json:"client_id" gorm:"not null;uniqueIndex:idx_oauth_consent"`
+	Scope     string    `json:"scope" gorm:"not null;default:''"`
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+// The `TableName` method names the table of the `OAuthConsent` model.
+func (OAuthConsent) TableName() string {
+	return "oauth_consents"
+}
+
+// The `GetConsent` method is used to retrieve the consent the given user has given the client with the
+// provided client ID.
+func (o *OAuthConsent) GetConsent(userID uint, clientID string) error {
+	return db.Model(&o).Where("user_id = ? AND client_id = ?", userID, clientID).First(&o).Error
+}
+
+// The `Save` method is used to create the consent record, or to replace the scope of the consent the
+// user has already given the client.
+func (o *OAuthConsent) Save() error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "client_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"scope", "updated_at"}),
+	}).Create(&o).Error
+}
+
+// The `Add` method adds the scopes of the given space separated scope to the scopes that have been
+// consented to.
+func (o *OAuthConsent) Add(scope string) {
+	scopes := strings.Fields(o.Scope)
+	for _, requested := range strings.Fields(scope) {
+		if !o.Covers(requested) {
+			scopes = append(scopes, requested)
+		}
+	}
+	o.Scope = strings.Join(scopes, " ")
+}
+
+// The `Covers` method reports if every scope of the given space separated scope has been consented to.
+func (o *OAuthConsent) Covers(scope string) bool {
+	for _, requested := range strings.Fields(scope) {
+		if !oauth.HasScope(o.Scope, requested) {
+			return false
+		}
+	}
+	return true
+}
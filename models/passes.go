@@ -1,22 +1,50 @@
 package models
 
+import "gorm.io/gorm"
+
+// The UsedPassword struct defines the structure of a previous password hash of a user, which is kept so
+// that a password can not be used again.
 type UsedPassword struct {
 	ID       uint   `json:"-" gorm:"primarykey"`
 	Password string `json:"-" gorm:"not null"`
-	UserID   uint   `json:"-" gorm:"not null,user_id"`
+	UserID   uint   `json:"-" gorm:"not null;index"`
 }
 
 // GetUsedPasswords returns all the used passwords for a user.
 func (u *User) GetUsedPasswords() []UsedPassword {
 	var usedPasswords []UsedPassword
-	db.Model(&u).Where("user_id = ?", u.ID).Find(&usedPasswords)
+	db.Model(&UsedPassword{}).Where("user_id = ?", u.ID).Order("id DESC").Find(&usedPasswords)
 	return usedPasswords
 }
 
 // AddUsedPassword adds a new used password for a user.
 func (u *User) AddUsedPassword(password string) {
-	db.Model(&u).Create(&UsedPassword{
+	db.Model(&UsedPassword{}).Create(&UsedPassword{
 		Password: password,
 		UserID:   u.ID,
 	})
 }
+
+// ChangePassword replaces the hashed password of a user and keeps the previous hash as a used password
+// in one transaction, so that a changed password is never missing from the history. Only the `keep`
+// most recent used passwords are kept.
+func (u *User) ChangePassword(hash string, keep int) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if keep > 0 {
+			if err := tx.Create(&UsedPassword{Password: u.Password, UserID: u.ID}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&User{}).Where("id = ?", u.ID).Update("password", hash).Error; err != nil {
+			return err
+		}
+
+		recent := tx.Model(&UsedPassword{}).Select("id").Where("user_id = ?", u.ID).Order("id DESC").Limit(keep)
+		return tx.Where("user_id = ? AND id NOT IN (?)", u.ID, recent).Delete(&UsedPassword{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	u.Password = hash
+	return nil
+}
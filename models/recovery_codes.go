@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The RecoveryCode struct defines the structure of an MFA recovery code in the database. Only the hash
+// of the code is stored, like a password.
+type RecoveryCode struct {
+	ID        uint      `json:"-" gorm:"primarykey"`
+	UserID    uint      `json:"-" gorm:"not null;index"`
+	Code      string    `json:"-" gorm:"not null"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// The `ReplaceRecoveryCodes` method is used to replace all the recovery codes of the given user with
+// the given hashed codes.
+func (r *RecoveryCode) ReplaceRecoveryCodes(userID uint, codes []string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+
+		records := make([]RecoveryCode, len(codes))
+		for i, code := range codes {
+			records[i] = RecoveryCode{UserID: userID, Code: code}
+		}
+		return tx.Create(&records).Error
+	})
+}
+
+// The `GetRecoveryCodes` method is used to retrieve the recovery codes of the given user that have not
+// been used yet.
+func (r *RecoveryCode) GetRecoveryCodes(userID uint) []RecoveryCode {
+	var codes []RecoveryCode
+	db.Model(&r).Where("user_id = ?", userID).Find(&codes)
+	return codes
+}
+
+// The `CountRecoveryCodes` method is used to count the recovery codes of the given user that have not
+// been used yet.
+func (r *RecoveryCode) CountRecoveryCodes(userID uint) int64 {
+	var count int64
+	db.Model(&r).Where("user_id = ?", userID).Count(&count)
+	return count
+}
+
+// The `Use` method is used to delete the recovery code once it has been used. It returns false if the
+// code has been used in the meantime, so that a code can not be used twice by concurrent requests.
+func (r *RecoveryCode) Use() bool {
+	result := db.Where("id = ?", r.ID).Delete(&RecoveryCode{})
+	return result.Error == nil && result.RowsAffected == 1
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The `sessionTouchInterval` constant is the minimum time between two updates of the last seen time of
+// a session, so that not every authenticated request results in a write.
+const sessionTouchInterval = time.Minute
+
+// The Session struct defines the structure of a login session in the database. The ID of a session is
+// also the ID of the refresh token family issued for it.
+type Session struct {
+	ID         string    `json:"id" gorm:"primarykey"`
+	UserID     uint      `json:"-" gorm:"not null;index"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current" gorm:"-"`
+}
+
+// The `Create()` method is used to create a new session record in the database.
+func (s *Session) Create() *Session {
+	s.LastSeenAt = time.Now()
+	db.Model(&s).Create(&s)
+	return s
+}
+
+// The `GetSession` method is used to retrieve a session of the given user based on the provided ID.
+func (s *Session) GetSession(id string, userID uint) error {
+	return db.Model(&s).Where("id = ? AND user_id = ?", id, userID).First(&s).Error
+}
+
+// The `GetSessionByID` method is used to retrieve a session based on the provided ID, regardless of the
+// user it belongs to.
+func (s *Session) GetSessionByID(id string) error {
+	return db.Model(&s).Where("id = ?", id).First(&s).Error
+}
+
+// The `IsExpired` method reports if the session has not been used for longer than `lifetime`, the
+// lifetime of its refresh tokens. Every rotation of the refresh token family of the session updates the
+// last seen time, which may lag behind by up to the touch interval, so that no token of an expired
+// session is valid any more.
+func (s *Session) IsExpired(lifetime time.Duration, now time.Time) bool {
+	return now.Sub(s.LastSeenAt) > lifetime+sessionTouchInterval
+}
+
+// The `DeleteExpiredSessions` method is used to delete the sessions of the given user that have expired
+// (see `IsExpired`).
+func (s *Session) DeleteExpiredSessions(userID uint, lifetime time.Duration) error {
+	expired := time.Now().Add(-lifetime - sessionTouchInterval)
+	return db.Where("user_id = ? AND last_seen_at < ?", userID, expired).Delete(&Session{}).Error
+}
+
+// The `GetUserSessions` method is used to retrieve all the sessions of the given user, most recently
+// used first.
+func (s *Session) GetUserSessions(userID uint) []Session {
+	var sessions []Session
+	db.Model(&s).Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&sessions)
+	return sessions
+}
+
+// The `Touch` method is used to retrieve the session with the given ID and to record that it has just
+// been used. It returns an error if the session does not exist (i.e. it has been revoked) and deletes
+// the session if it has expired (see `IsExpired`).
+func (s *Session) Touch(id string, lifetime time.Duration) error {
+	if err := db.Model(&s).Where("id = ?", id).First(&s).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if s.IsExpired(lifetime, now) {
+		s.Delete()
+		return gorm.ErrRecordNotFound
+	}
+	if now.Sub(s.LastSeenAt) < sessionTouchInterval {
+		return nil
+	}
+	s.LastSeenAt = now
+	return db.Model(&s).Update("last_seen_at", now).Error
+}
+
+// The `Delete` method is used to delete the session record from the database.
+func (s *Session) Delete() error {
+	return db.Where("id = ?", s.ID).Delete(&Session{}).Error
+}
+
+// The `DeleteOtherSessions` method is used to delete all the sessions of the given user except the
+// session with the given ID. It returns the deleted sessions.
+func (s *Session) DeleteOtherSessions(userID uint, current string) ([]Session, error) {
+	var sessions []Session
+	err := db.Model(&s).Where("user_id = ? AND id <> ?", userID, current).Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return sessions, nil
+	}
+
+	err = db.Where("user_id = ? AND id <> ?", userID, current).Delete(&Session{}).Error
+	return sessions, err
+}
+
+// The `DeleteUserSessions` method is used to delete all the sessions of the given user. It returns the
+// deleted sessions.
+func (s *Session) DeleteUserSessions(userID uint) ([]Session, error) {
+	return s.DeleteOtherSessions(userID, "")
+}
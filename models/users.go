@@ -5,6 +5,7 @@ import (
 	"time"
 
 	sql "coderero.dev/projects/go/gin/hello/db"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
 	"gorm.io/gorm"
 )
 
@@ -14,21 +15,62 @@ var db *gorm.DB
 
 func init() {
 	db = sql.GetDB()
-	db.AutoMigrate(&User{})
+
+	// The users that registered before email addresses were verified keep their access, so their
+	// addresses are taken as verified when the column is added.
+	verifiedColumn := db.Migrator().HasColumn(&User{}, "EmailVerified")
+	db.AutoMigrate(&User{}, &Session{}, &OAuthClient{}, &OAuthConsent{}, &WebAuthnCredential{}, &RecoveryCode{}, &UsedPassword{})
+	if !verifiedColumn {
+		db.Model(&User{}).Where("1 = 1").Update("email_verified", true)
+	}
+	backfillUUIDs()
 }
 
-// The User struct defines the structure of a user record in the database.
+// The User struct defines the structure of a user record in the database. The `UUID` is the immutable,
+// non-guessable identifier of the user that is used as the subject of the tokens. A user can only log
+// in once the email address has been verified. The TOTP secret is stored as soon as the user starts the
+// enrollment, but it is only asked for once the user has confirmed it with a first code.
 type User struct {
-	ID        uint           `json:"-" gorm:"primarykey"`
-	Username  string         `json:"username,omitempty" gorm:"unique;not null"`
-	Email     string         `json:"email,omitempty" gorm:"unique;not null"`
-	Password  string         `json:"-" gorm:"not null"`
-	FirstName string         `json:"firstname,omitempty" gorm:"not null"`
-	LastName  string         `json:"lastname,omitempty" gorm:"not null"`
-	Age       int            `json:"age,omitempty" gorm:"not null"`
-	CreatedAt time.Time      `json:"-"`
-	UpdatedAt time.Time      `json:"-"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID            uint           `json:"-" gorm:"primarykey"`
+	UUID          string         `json:"id" gorm:"uniqueIndex;size:36"`
+	Username      string         `json:"username,omitempty" gorm:"unique;not null"`
+	Email         string         `json:"email,omitempty" gorm:"unique;not null"`
+	EmailVerified bool           `json:"email_verified" gorm:"not null;default:false"`
+	Password      string         `json:"-" gorm:"not null"`
+	FirstName     string         `json:"firstname,omitempty" gorm:"not null"`
+	LastName      string         `json:"lastname,omitempty" gorm:"not null"`
+	Age           int            `json:"age,omitempty" gorm:"not null"`
+	TOTPSecret    string         `json:"-" gorm:"column:totp_secret"`
+	TOTPEnabled   bool           `json:"-" gorm:"column:totp_enabled;not null;default:false"`
+	CreatedAt     time.Time      `json:"-"`
+	UpdatedAt     time.Time      `json:"-"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// The `BeforeCreate` hook assigns a UUID to every new user.
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.UUID != "" {
+		return nil
+	}
+	id, err := utils.NewUUID()
+	if err != nil {
+		return err
+	}
+	u.UUID = id
+	return nil
+}
+
+// The function `backfillUUIDs` assigns a UUID to the users that were created before users had one.
+func backfillUUIDs() {
+	var users []User
+	db.Model(&User{}).Where("uuid IS NULL OR uuid = ''").Find(&users)
+	for _, user := range users {
+		id, err := utils.NewUUID()
+		if err != nil {
+			panic(err)
+		}
+		db.Model(&User{}).Where("id = ?", user.ID).Update("uuid", id)
+	}
 }
 
 // The above code defines a User struct and provides methods for creating, retrieving, updating, and
@@ -98,6 +140,12 @@ func (u *User) GetUserByEmail(email string) error {
 	return m.Error
 }
 
+// The `GetUserByUUID` method is used to retrieve a user record from the database based on the provided
+// UUID.
+func (u *User) GetUserByUUID(uuid string) error {
+	return db.Model(&u).Where("uuid = ?", uuid).First(&u).Error
+}
+
 // The `GetUserByUsername` method is used to retrieve a user record from the database based on the
 // provided username. It takes the username as a parameter and returns a pointer to the retrieved user
 // (`*User`).
@@ -137,3 +185,40 @@ func (u *User) DeleteByEmail(email string) error {
 	model.Unscoped().Delete(u)
 	return nil
 }
+
+// The `SetTOTPSecret` method is used to store the TOTP secret of a pending enrollment. Two-factor
+// authentication stays disabled until `EnableTOTP` is called.
+func (u *User) SetTOTPSecret(secret string) error {
+	u.TOTPSecret, u.TOTPEnabled = secret, false
+	return db.Model(&User{}).Where("id = ?", u.ID).Updates(map[string]any{"totp_secret": secret, "totp_enabled": false}).Error
+}
+
+// The `EnableTOTP` method is used to enable two-factor authentication with the stored TOTP secret.
+func (u *User) EnableTOTP() error {
+	u.TOTPEnabled = true
+	return db.Model(&User{}).Where("id = ?", u.ID).Update("totp_enabled", true).Error
+}
+
+// The `DisableTOTP` method is used to disable two-factor authentication and to remove the TOTP secret.
+func (u *User) DisableTOTP() error {
+	return u.SetTOTPSecret("")
+}
+
+// The `SetEmailVerified` method is used to record if the email address of the user has been verified.
+func (u *User) SetEmailVerified(verified bool) error {
+	u.EmailVerified = verified
+	return db.Model(&User{}).Where("id = ?", u.ID).Update("email_verified", verified).Error
+}
+
+// The `ChangeEmail` method is used to replace the email address of the user. The new address has not
+// been verified yet, so the address and the cleared verified flag are written in one update.
+func (u *User) ChangeEmail(email string) error {
+	u.Email, u.EmailVerified = email, false
+	return db.Model(&User{}).Where("id = ?", u.ID).Updates(map[string]any{"email": email, "email_verified": false}).Error
+}
+
+// The `SetPassword` method is used to replace the hashed password of the user.
+func (u *User) SetPassword(hash string) error {
+	u.Password = hash
+	return db.Model(&User{}).Where("id = ?", u.ID).Update("password", hash).Error
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// The WebAuthnCredential struct defines the structure of a WebAuthn credential (passkey) registered by
+// a user in the database. The `CredentialID` is the base64url encoded ID the authenticator assigned to
+// the credential and `PublicKey` is the COSE encoded public key of the credential.
+type WebAuthnCredential struct {
+	ID           uint       `json:"-" gorm:"primarykey"`
+	UserID       uint       `json:"-" gorm:"not null;index"`
+	CredentialID string     `json:"id" gorm:"uniqueIndex;not null"`
+	PublicKey    []byte     `json:"-" gorm:"not null"`
+	SignCount    uint32     `json:"-" gorm:"not null"`
+	Name         string     `json:"name"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+}
+
+// The `TableName` method sets the name of the table of the WebAuthn credentials.
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
+
+// The `Create()` method is used to create a new credential record in the database.
+func (w *WebAuthnCredential) Create() error {
+	return db.Model(&w).Create(&w).Error
+}
+
+// The `GetCredential` method is used to retrieve a credential based on the provided credential ID.
+func (w *WebAuthnCredential) GetCredential(credentialID string) error {
+	return db.Model(&w).Where("credential_id = ?", credentialID).First(&w).Error
+}
+
+// The `GetUserCredentials` method is used to retrieve all the credentials of the given user.
+func (w *WebAuthnCredential) GetUserCredentials(userID uint) []WebAuthnCredential {
+	var credentials []WebAuthnCredential
+	db.Model(&w).Where("user_id = ?", userID).Order("created_at").Find(&credentials)
+	return credentials
+}
+
+// The `Used` method is used to record a sign in with the credential and the new sign count reported by
+// the authenticator.
+func (w *WebAuthnCredential) Used(signCount uint32) error {
+	now := time.Now()
+	w.SignCount, w.LastUsedAt = signCount, &now
+	return db.Model(&w).Updates(map[string]any{"sign_count": signCount, "last_used_at": now}).Error
+}
@@ -0,0 +1,24 @@
+package lockout
+
+import "time"
+
+// The `Policy` struct holds the limits of the failed logins of an account. After every failed login the
+// next attempt is delayed by a back-off that doubles from `BackoffBase` up to `BackoffMax`, and after
+// `Threshold` consecutive failures the account is locked for `Duration`. The failures are forgotten
+// after `Duration` without a failed login.
+type Policy struct {
+	Threshold   int64
+	Duration    time.Duration
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+// The `Backoff` method returns the delay before the next login attempt after the given number of
+// consecutive failed logins.
+func (p Policy) Backoff(failures int64) time.Duration {
+	delay := p.BackoffBase
+	for i := int64(1); i < failures && delay < p.BackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, p.BackoffMax)
+}
@@ -0,0 +1,63 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+// The `Message` struct holds a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// The `Transport` interface is implemented by the ways mail can be sent. The SMTP transport is used in
+// production; the file and in-memory outboxes let the mail be read without a mail server.
+type Transport interface {
+	Send(msg Message) error
+}
+
+// The `transport` variable is the transport `Send` uses. It is selected with the `MAIL_TRANSPORT`
+// environment variable: "smtp", "file" (the default, which writes to `MAIL_OUTBOX_DIR`) or "memory".
+var transport Transport
+
+func init() {
+	godotenv.Load()
+
+	switch kind := os.Getenv("MAIL_TRANSPORT"); kind {
+	case "smtp":
+		transport = &SMTPTransport{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		}
+	case "", "file":
+		dir := os.Getenv("MAIL_OUTBOX_DIR")
+		if dir == "" {
+			dir = "./outbox"
+		}
+		transport = &FileTransport{Dir: dir, From: os.Getenv("MAIL_FROM")}
+	case "memory":
+		transport = &MemoryTransport{}
+	default:
+		panic(fmt.Errorf("mail: unknown MAIL_TRANSPORT %q", kind))
+	}
+}
+
+// The function `Send` sends the message with the configured transport.
+func Send(msg Message) error {
+	return transport.Send(msg)
+}
+
+// The function `SetTransport` replaces the transport `Send` uses, e.g. with a `MemoryTransport` in
+// tests. It returns the transport that was used before.
+func SetTransport(t Transport) Transport {
+	previous := transport
+	transport = t
+	return previous
+}
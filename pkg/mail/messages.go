@@ -0,0 +1,97 @@
+package mail
+
+import (
+	"fmt"
+	"time"
+)
+
+// The function `VerificationMessage` returns the message with the link that verifies the email address
+// of a new account.
+func VerificationMessage(to string, name string, link string, expires time.Duration) Message {
+	return Message{
+		To:      to,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(`Hi %s,
+
+please verify your email address by opening the link below:
+
+%s
+
+The link expires in %s. If you did not create an account, you can ignore this email.
+`, name, link, humanDuration(expires)),
+	}
+}
+
+// The function `PasswordResetMessage` returns the message with the link that lets a user set a new
+// password.
+func PasswordResetMessage(to string, name string, link string, expires time.Duration) Message {
+	return Message{
+		To:      to,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(`Hi %s,
+
+a password reset was requested for your account. Open the link below to choose a new password:
+
+%s
+
+The link expires in %s and can only be used once. If you did not request a password reset, you can
+ignore this email; your password has not been changed.
+`, name, link, humanDuration(expires)),
+	}
+}
+
+// The function `MagicLinkMessage` returns the message with the link that signs a user in without a
+// password.
+func MagicLinkMessage(to string, name string, link string, expires time.Duration) Message {
+	return Message{
+		To:      to,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf(`Hi %s,
+
+open the link below in the same browser you asked for it from to sign in:
+
+%s
+
+The link expires in %s and can only be used once. If you did not ask to sign in, you can ignore this
+email.
+`, name, link, humanDuration(expires)),
+	}
+}
+
+// The function `AccountLockedMessage` returns the message that tells a user that the account has been
+// locked after too many failed logins, with the link that unlocks it.
+func AccountLockedMessage(to string, name string, link string, expires time.Duration) Message {
+	return Message{
+		To:      to,
+		Subject: "Your account has been locked",
+		Body: fmt.Sprintf(`Hi %s,
+
+your account has been locked after too many failed logins. It is unlocked again in %s, or right away
+by opening the link below:
+
+%s
+
+If the failed logins were not you, someone may be trying to guess your password. Consider choosing a
+new one with the "forgot password" page, which unlocks the account as well.
+`, name, humanDuration(expires), link),
+	}
+}
+
+// The function `humanDuration` formats a duration the way it is read in an email, e.g. "24 hours".
+func humanDuration(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return plural(int(d/time.Hour), "hour")
+	case d >= time.Minute && d%time.Minute == 0:
+		return plural(int(d/time.Minute), "minute")
+	}
+	return d.String()
+}
+
+// The function `plural` formats a count of a unit, e.g. "1 hour" or "2 hours".
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,58 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// The `FileTransport` struct writes every message to its own file in a directory instead of sending
+// it, so that the mail can be read during development.
+type FileTransport struct {
+	Dir  string
+	From string
+}
+
+// The `Send` method writes the message to a new `.eml` file in the directory.
+func (t *FileTransport) Send(msg Message) error {
+	if err := os.MkdirAll(t.Dir, 0o700); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d.eml", time.Now().UnixNano())
+	return os.WriteFile(filepath.Join(t.Dir, name), format(t.From, msg), 0o600)
+}
+
+// The `MemoryTransport` struct keeps the sent messages in memory, so that tests can read them.
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// The `Send` method appends the message to the outbox.
+func (t *MemoryTransport) Send(msg Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = append(t.messages, msg)
+	return nil
+}
+
+// The `Messages` method returns the messages sent so far.
+func (t *MemoryTransport) Messages() []Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Message(nil), t.messages...)
+}
+
+// The `Last` method returns the last message sent to the given address.
+func (t *MemoryTransport) Last(to string) (Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := len(t.messages) - 1; i >= 0; i-- {
+		if t.messages[i].To == to {
+			return t.messages[i], true
+		}
+	}
+	return Message{}, false
+}
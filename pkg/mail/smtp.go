@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// The `SMTPTransport` struct sends mail through an SMTP server. The server is authenticated with PLAIN
+// authentication if a username is set, which `net/smtp` only allows over TLS or to localhost.
+type SMTPTransport struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// The `Send` method sends the message through the SMTP server.
+func (t *SMTPTransport) Send(msg Message) error {
+	var auth smtp.Auth
+	if t.Username != "" {
+		auth = smtp.PlainAuth("", t.Username, t.Password, t.Host)
+	}
+	return smtp.SendMail(net.JoinHostPort(t.Host, t.Port), auth, t.From, []string{msg.To}, format(t.From, msg))
+}
+
+// The function `format` formats the message as an RFC 5322 email. Line breaks are removed from the
+// header values, so that a value can not add headers.
+func format(from string, msg Message) []byte {
+	header := strings.NewReplacer("\r", "", "\n", "")
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", header.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", header.Replace(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", header.Replace(msg.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
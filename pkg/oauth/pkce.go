@@ -0,0 +1,33 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+)
+
+// The `pkcePattern` matches the code verifiers and code challenges allowed by RFC 7636: 43 to 128
+// unreserved characters.
+var pkcePattern = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// The function `IsValidCodeChallenge` checks if a code challenge has the format required by RFC 7636.
+func IsValidCodeChallenge(challenge string) bool {
+	return pkcePattern.MatchString(challenge)
+}
+
+// The function `CodeChallenge` returns the S256 code challenge of a code verifier (RFC 7636, section
+// 4.2).
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// The function `VerifyCodeVerifier` checks if the code verifier of a token request matches the S256
+// code challenge of the authorization request (RFC 7636, section 4.6).
+func VerifyCodeVerifier(verifier string, challenge string) bool {
+	if !pkcePattern.MatchString(verifier) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(CodeChallenge(verifier)), []byte(challenge)) == 1
+}
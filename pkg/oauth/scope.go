@@ -0,0 +1,30 @@
+package oauth
+
+import "strings"
+
+// The function `HasScope` reports if the space separated scope contains the given scope.
+func HasScope(scope string, want string) bool {
+	for _, s := range strings.Fields(scope) {
+		if s == want {
+			return true
+		}
+	}
+	return false
+}
+
+// The function `GrantedScope` returns the scope that is granted for the requested scope out of the
+// allowed one, both space separated. Every requested scope has to be allowed; if no scope is requested,
+// all the allowed scopes are granted.
+func GrantedScope(allowed string, requested string) (string, bool) {
+	if strings.TrimSpace(requested) == "" {
+		return strings.Join(strings.Fields(allowed), " "), true
+	}
+
+	scopes := strings.Fields(requested)
+	for _, scope := range scopes {
+		if !HasScope(allowed, scope) {
+			return "", false
+		}
+	}
+	return strings.Join(scopes, " "), true
+}
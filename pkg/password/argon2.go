@@ -0,0 +1,73 @@
+package password
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// The `argon2idID` constant is the PHC identifier of Argon2id.
+const argon2idID = "argon2id"
+
+// The `Argon2id` struct holds the parameters of Argon2id (RFC 9106): the memory in KiB, the number of
+// passes, the degree of parallelism and the length of the derived key in bytes. The hashes are encoded
+// as `$argon2id$v=19$m=<Memory>,t=<Time>,p=<Threads>$<salt>$<key>`.
+type Argon2id struct {
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+	KeyLen  uint32
+}
+
+// The `Hash` method hashes the password with Argon2id.
+func (a Argon2id) Hash(password string) (string, error) {
+	salt, err := newSalt()
+	if err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, a.KeyLen)
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2idID, argon2.Version, a.Memory, a.Time, a.Threads, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+func (a Argon2id) weakerThan(other Algorithm) bool {
+	o := other.(Argon2id)
+	return a.Memory < o.Memory || a.Time < o.Time || a.Threads < o.Threads || a.KeyLen < o.KeyLen
+}
+
+// The function `parseArgon2id` reads the parameters, the salt and the key of an Argon2id PHC string.
+// The memory is bounded to 4 GiB, so that a malformed hash can not make the server allocate more.
+func parseArgon2id(encoded string) (Argon2id, []byte, []byte, error) {
+	version, params, salt, key, err := parsePHC(encoded, argon2idID)
+	if err != nil || version != strconv.Itoa(argon2.Version) {
+		return Argon2id{}, nil, nil, MalformedHashError
+	}
+	memory, err := intParam(params, "m", 4<<20)
+	if err != nil {
+		return Argon2id{}, nil, nil, err
+	}
+	time, err := intParam(params, "t", 1<<16)
+	if err != nil {
+		return Argon2id{}, nil, nil, err
+	}
+	threads, err := intParam(params, "p", 255)
+	if err != nil {
+		return Argon2id{}, nil, nil, err
+	}
+	a := Argon2id{Memory: uint32(memory), Time: uint32(time), Threads: uint8(threads), KeyLen: uint32(len(key))}
+	return a, salt, key, nil
+}
+
+// The function `verifyArgon2id` checks the password against an Argon2id PHC string.
+func verifyArgon2id(password string, encoded string) error {
+	a, salt, key, err := parseArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+	derived := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, a.KeyLen)
+	if subtle.ConstantTimeCompare(derived, key) != 1 {
+		return MismatchError
+	}
+	return nil
+}
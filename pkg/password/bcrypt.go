@@ -0,0 +1,48 @@
+package password
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// The `Bcrypt` struct holds the cost of bcrypt. The hashes keep the modular crypt format of bcrypt
+// (`$2a$<cost>$<salt and hash>`). Only the first 72 bytes of a password are used by bcrypt, so longer
+// passwords are rejected.
+type Bcrypt struct {
+	Cost int
+}
+
+// The `Hash` method hashes the password with bcrypt.
+func (b Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (b Bcrypt) weakerThan(other Algorithm) bool {
+	return b.Cost < other.(Bcrypt).Cost
+}
+
+// The function `parseBcrypt` reads the cost of a bcrypt hash.
+func parseBcrypt(encoded string) (Bcrypt, error) {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return Bcrypt{}, MalformedHashError
+	}
+	return Bcrypt{Cost: cost}, nil
+}
+
+// The function `verifyBcrypt` checks the password against a bcrypt hash.
+func verifyBcrypt(password string, encoded string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword), errors.Is(err, bcrypt.ErrPasswordTooLong):
+		return MismatchError
+	}
+	return MalformedHashError
+}
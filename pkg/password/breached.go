@@ -0,0 +1,72 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The `prefixLength` constant is the length of the SHA-1 prefix, in hex characters, the files of a
+// breached password list are named after.
+const prefixLength = 5
+
+// The `BreachedList` struct is a local list of breached or common passwords in the format of the range
+// files of the Pwned Passwords API: the SHA-1 hashes of the passwords are split by their first five hex
+// characters into files named after the prefix (e.g. `21BD1` or `21BD1.txt`), and every line of a file
+// is the rest of a hash followed by a colon and the number of times it has been seen. Only the file of
+// the prefix of a password is read to look it up.
+type BreachedList struct {
+	Dir string
+}
+
+// The `Contains` method reports if the password is on the list.
+func (l *BreachedList) Contains(password string) (bool, error) {
+	prefix, suffix := hashRange(password)
+	file, err := l.open(prefix)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(line, suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// The `open` method opens the range file of the prefix, with or without the `.txt` extension.
+func (l *BreachedList) open(prefix string) (*os.File, error) {
+	file, err := os.Open(filepath.Join(l.Dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		file, err = os.Open(filepath.Join(l.Dir, prefix))
+	}
+	return file, err
+}
+
+// The function `RangeFile` returns the name of the range file of the password and the line that lists
+// it, so that a list of common passwords can be converted into the format of the breached password
+// list.
+func RangeFile(password string) (string, string) {
+	prefix, suffix := hashRange(password)
+	return prefix + ".txt", suffix + ":1"
+}
+
+// The function `hashRange` returns the prefix and the rest of the uppercase hex SHA-1 hash of the
+// password.
+func hashRange(password string) (string, string) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return hash[:prefixLength], hash[prefixLength:]
+}
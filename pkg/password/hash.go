@@ -0,0 +1,158 @@
+package password
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The `Algorithm` interface is a password hashing algorithm with its parameters. The hashes are stored
+// as PHC strings (`$<id>$<param>=<value>,...$<salt>$<hash>`), except for bcrypt, which keeps its own
+// modular crypt format.
+type Algorithm interface {
+	// The `Hash` method hashes the password with a new random salt and returns the encoded hash.
+	Hash(password string) (string, error)
+	// The `weakerThan` method reports if the parameters are weaker than the ones of the other
+	// algorithm, which has to be the same algorithm.
+	weakerThan(other Algorithm) bool
+}
+
+// The errors returned for a hash that can not be verified.
+var (
+	MismatchError         = errors.New("pass: provided password does not match the actual password")
+	MalformedHashError    = errors.New("pass: malformed password hash")
+	UnknownAlgorithmError = errors.New("pass: unknown password hashing algorithm")
+)
+
+// The `saltLength` constant is the length of the random salts in bytes.
+const saltLength = 16
+
+// The `b64` variable is the unpadded standard base64 encoding the salts and hashes of the PHC strings
+// are encoded with.
+var b64 = base64.RawStdEncoding
+
+// The function `Verify` checks the password against an encoded hash of any of the supported
+// algorithms, including the legacy `$disto$` format. It returns `MismatchError` if the password does not
+// match, and `MalformedHashError` or `UnknownAlgorithmError` if the hash can not be read. The hashes of
+// peppered passwords are verified with `VerifyWithPeppers`.
+func Verify(password string, encoded string) error {
+	switch algorithmID(encoded) {
+	case pepperID:
+		return UnknownPepperError
+	case legacyID:
+		return verifyLegacy(password, encoded)
+	case scryptID:
+		return verifyScrypt(password, encoded)
+	case argon2idID:
+		return verifyArgon2id(password, encoded)
+	case "2a", "2b", "2y":
+		return verifyBcrypt(password, encoded)
+	}
+	return UnknownAlgorithmError
+}
+
+// The function `Identify` returns the algorithm and the parameters an encoded hash was created with.
+// Legacy `$disto$` hashes are reported as scrypt hashes, and the hashes of peppered passwords as the
+// algorithm the peppered password was hashed with.
+func Identify(encoded string) (Algorithm, error) {
+	if _, hash, ok := splitPepper(encoded); ok {
+		encoded = hash
+	}
+	switch algorithmID(encoded) {
+	case legacyID:
+		params, _, _, err := parseLegacy(encoded)
+		return params, err
+	case scryptID:
+		params, _, _, err := parseScrypt(encoded)
+		return params, err
+	case argon2idID:
+		params, _, _, err := parseArgon2id(encoded)
+		return params, err
+	case "2a", "2b", "2y":
+		return parseBcrypt(encoded)
+	}
+	return nil, UnknownAlgorithmError
+}
+
+// The function `NeedsRehash` reports if an encoded hash should be replaced with a hash of the current
+// algorithm: if it was created with another algorithm, with weaker parameters, in the legacy format,
+// or if it can not be read. The pepper of the hash is not taken into account (see `PepperID`).
+func NeedsRehash(encoded string, current Algorithm) bool {
+	if _, hash, ok := splitPepper(encoded); ok {
+		encoded = hash
+	}
+	if algorithmID(encoded) == legacyID {
+		return true
+	}
+	params, err := Identify(encoded)
+	if err != nil {
+		return true
+	}
+	if fmt.Sprintf("%T", params) != fmt.Sprintf("%T", current) {
+		return true
+	}
+	return params.weakerThan(current)
+}
+
+// The function `algorithmID` returns the identifier of the algorithm of an encoded hash, which is the
+// first field of the string.
+func algorithmID(encoded string) string {
+	if !strings.HasPrefix(encoded, "$") {
+		return ""
+	}
+	id, _, _ := strings.Cut(encoded[1:], "$")
+	return id
+}
+
+// The function `newSalt` returns a new random salt.
+func newSalt() ([]byte, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// The function `parsePHC` splits a PHC string with the given algorithm identifier into its version,
+// which is empty if the string has none, its parameters, its salt and its hash.
+func parsePHC(encoded string, id string) (string, map[string]string, []byte, []byte, error) {
+	fields := strings.Split(encoded, "$")
+	var version string
+	if len(fields) == 6 && strings.HasPrefix(fields[2], "v=") {
+		version = strings.TrimPrefix(fields[2], "v=")
+		fields = append(fields[:2], fields[3:]...)
+	}
+	if len(fields) != 5 || fields[0] != "" || fields[1] != id {
+		return "", nil, nil, nil, MalformedHashError
+	}
+
+	params := make(map[string]string)
+	for _, param := range strings.Split(fields[2], ",") {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok || name == "" {
+			return "", nil, nil, nil, MalformedHashError
+		}
+		params[name] = value
+	}
+	salt, err := b64.DecodeString(fields[3])
+	if err != nil || len(salt) == 0 {
+		return "", nil, nil, nil, MalformedHashError
+	}
+	hash, err := b64.DecodeString(fields[4])
+	if err != nil || len(hash) == 0 {
+		return "", nil, nil, nil, MalformedHashError
+	}
+	return version, params, salt, hash, nil
+}
+
+// The function `intParam` reads a positive integer parameter of a PHC string that is at most `max`.
+func intParam(params map[string]string, name string, max int) (int, error) {
+	value, err := strconv.Atoi(params[name])
+	if err != nil || value < 1 || value > max {
+		return 0, MalformedHashError
+	}
+	return value, nil
+}
@@ -0,0 +1,40 @@
+package password
+
+import (
+	"strings"
+)
+
+// The legacy format of the password hashes, `$disto$<log2 N>$<r>$<salt><key>`, is scrypt with p = 1
+// and a 16 byte key, where the salt and the key are concatenated in unpadded base64 and the salt takes
+// the first 31 characters. It is still verified, but no longer written.
+const (
+	legacyID         = "disto"
+	legacySaltLength = 31
+	legacyKeyLength  = 16
+)
+
+// The function `parseLegacy` reads the parameters, the salt and the key of a legacy hash.
+func parseLegacy(encoded string) (Scrypt, []byte, []byte, error) {
+	fields := strings.Split(encoded, "$")
+	if len(fields) != 5 || fields[0] != "" || fields[1] != legacyID || len(fields[4]) <= legacySaltLength {
+		return Scrypt{}, nil, nil, MalformedHashError
+	}
+	salt, err := b64.DecodeString(fields[4][:legacySaltLength])
+	if err != nil {
+		return Scrypt{}, nil, nil, MalformedHashError
+	}
+	key, err := b64.DecodeString(fields[4][legacySaltLength:])
+	if err != nil || len(key) != legacyKeyLength {
+		return Scrypt{}, nil, nil, MalformedHashError
+	}
+	return scryptParams(fields[2], fields[3], "1", salt, key)
+}
+
+// The function `verifyLegacy` checks the password against a legacy hash.
+func verifyLegacy(password string, encoded string) error {
+	s, salt, key, err := parseLegacy(encoded)
+	if err != nil {
+		return err
+	}
+	return compareScrypt(password, s, salt, key)
+}
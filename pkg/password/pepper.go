@@ -0,0 +1,123 @@
+package password
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The `pepperID` constant is the identifier of the hashes of peppered passwords. They are stored as
+// `$pepper$kid=<key ID>` followed by the hash of the peppered password, so that the key a hash was
+// created with is known when the keys are rotated.
+const pepperID = "pepper"
+
+// The `pepperKeyIDPattern` matches the valid key IDs of peppers.
+var pepperKeyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// The errors returned for peppers and peppered hashes.
+var (
+	UnknownPepperError = errors.New("pass: the hash was created with an unknown pepper")
+	InvalidPepperError = errors.New("pass: a pepper needs a key ID of up to 32 letters, digits, '-' or '_' and a key of at least 16 bytes")
+)
+
+// The `Pepper` struct is a secret key that is kept outside of the database. Passwords are replaced with
+// their HMAC-SHA256 under the key before they are hashed, so that the hashes of a database dump can not
+// be brute-forced without the key as well.
+type Pepper struct {
+	ID  string
+	Key []byte
+}
+
+// The function `NewPepper` returns the pepper with the given key ID and key, which are validated.
+func NewPepper(id string, key []byte) (Pepper, error) {
+	if !pepperKeyIDPattern.MatchString(id) || len(key) < 16 {
+		return Pepper{}, InvalidPepperError
+	}
+	return Pepper{ID: id, Key: key}, nil
+}
+
+// The `Hash` method peppers the password and hashes it with the algorithm.
+func (p Pepper) Hash(algorithm Algorithm, password string) (string, error) {
+	hash, err := algorithm.Hash(p.apply(password))
+	if err != nil {
+		return "", err
+	}
+	return "$" + pepperID + "$kid=" + p.ID + hash, nil
+}
+
+// The `apply` method returns the HMAC-SHA256 of the password under the key of the pepper, encoded in
+// base64, which is short enough for every algorithm.
+func (p Pepper) apply(password string) string {
+	mac := hmac.New(sha256.New, p.Key)
+	mac.Write([]byte(password))
+	return b64.EncodeToString(mac.Sum(nil))
+}
+
+// The function `VerifyWithPeppers` checks the password against an encoded hash like `Verify`. The
+// hashes of peppered passwords are verified with the pepper of their key ID, and `UnknownPepperError`
+// is returned if it is not one of the given peppers.
+func VerifyWithPeppers(password string, encoded string, peppers map[string]Pepper) error {
+	id, hash, ok := splitPepper(encoded)
+	if !ok {
+		return Verify(password, encoded)
+	}
+	pepper, ok := peppers[id]
+	if !ok {
+		return UnknownPepperError
+	}
+	return Verify(pepper.apply(password), hash)
+}
+
+// The function `PepperID` returns the key ID of the pepper of an encoded hash, or an empty string if
+// the password was not peppered.
+func PepperID(encoded string) string {
+	id, _, _ := splitPepper(encoded)
+	return id
+}
+
+// The function `splitPepper` splits the hash of a peppered password into the key ID of the pepper and
+// the hash of the peppered password.
+func splitPepper(encoded string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(encoded, "$"+pepperID+"$kid=")
+	if !ok {
+		return "", "", false
+	}
+	i := strings.Index(rest, "$")
+	if i < 0 || !pepperKeyIDPattern.MatchString(rest[:i]) {
+		return "", "", false
+	}
+	return rest[:i], rest[i:], true
+}
+
+// The function `ParsePeppers` parses a list of `<key ID>:<base64 key>` entries separated by newlines,
+// commas or spaces. It returns the first pepper, which is nil for an empty list, and all of the peppers
+// by key ID.
+func ParsePeppers(list string) (*Pepper, map[string]Pepper, error) {
+	var current *Pepper
+	all := make(map[string]Pepper)
+	for _, entry := range strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	}) {
+		id, encoded, _ := strings.Cut(entry, ":")
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, nil, fmt.Errorf("pepper %q: the key is not valid base64", id)
+		}
+		pepper, err := NewPepper(id, key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("pepper %q: %w", id, err)
+		}
+		if _, ok := all[id]; ok {
+			return nil, nil, fmt.Errorf("pepper %q: the key ID is used twice", id)
+		}
+		all[id] = pepper
+		if current == nil {
+			current = &pepper
+		}
+	}
+	return current, all, nil
+}
@@ -0,0 +1,103 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"coderero.dev/projects/go/gin/hello/types"
+)
+
+// The `Policy` struct holds the rules a new password has to follow. The length is counted in
+// characters, and `MinClasses` is the number of character classes (lowercase and uppercase letters,
+// digits and symbols) the password has to contain. `MaxBytes` is the limit of the hashing algorithm,
+// which is counted in bytes of UTF-8 and is not enforced if zero. Passwords that contain the username
+// or the email address of the user are rejected, and so are the passwords of the breached password
+// list, if any.
+type Policy struct {
+	MinLength  int
+	MaxLength  int
+	MaxBytes   int
+	MinClasses int
+	Breached   *BreachedList
+}
+
+// The `personalMinLength` constant is the minimum length of the username or of the local part of the
+// email address that is looked for in the password, so that very short names do not reject most
+// passwords.
+const personalMinLength = 3
+
+// The `Check` method checks the password against every rule of the policy and returns one error for
+// every rule it breaks, on the given field. The `personal` values are the username and the email address
+// of the user, which the password must not contain.
+func (p Policy) Check(field string, password string, personal ...string) ([]types.APIError, error) {
+	var errs []types.APIError
+	fail := func(format string, args ...any) {
+		errs = append(errs, types.APIError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		fail("must be at least %d characters long", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		fail("must be at most %d characters long", p.MaxLength)
+	} else if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		fail("must be at most %d bytes long, and characters other than ASCII take up to 4 bytes", p.MaxBytes)
+	}
+	if classes := characterClasses(password); classes < p.MinClasses {
+		fail("must contain at least %d of: lowercase letters, uppercase letters, digits and symbols", p.MinClasses)
+	}
+	if containsPersonal(password, personal) {
+		fail("must not contain your username or email address")
+	}
+
+	if p.Breached != nil {
+		breached, err := p.Breached.Contains(password)
+		if err != nil {
+			return nil, err
+		}
+		if breached {
+			fail("is a common password or has appeared in a data breach, please choose another one")
+		}
+	}
+	return errs, nil
+}
+
+// The function `characterClasses` returns the number of character classes the password contains.
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+// The function `containsPersonal` reports if the password contains one of the values, ignoring case.
+// The local part of an email address is looked for as well.
+func containsPersonal(password string, values []string) bool {
+	password = strings.ToLower(password)
+	for _, value := range values {
+		value = strings.ToLower(value)
+		candidates := []string{value}
+		if local, _, ok := strings.Cut(value, "@"); ok {
+			candidates = append(candidates, local)
+		}
+		for _, candidate := range candidates {
+			if utf8.RuneCountInString(candidate) >= personalMinLength && strings.Contains(password, candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
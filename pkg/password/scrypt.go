@@ -0,0 +1,88 @@
+package password
+
+import (
+	"crypto/subtle"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// The `scryptID` constant is the PHC identifier of scrypt.
+const scryptID = "scrypt"
+
+// The `Scrypt` struct holds the parameters of scrypt (RFC 7914): the base 2 logarithm of the CPU and
+// memory cost `N`, the block size `R`, the parallelization `P` and the length of the derived key in
+// bytes. The hashes are encoded as `$scrypt$ln=<LogN>,r=<R>,p=<P>$<salt>$<key>`.
+type Scrypt struct {
+	LogN   int
+	R      int
+	P      int
+	KeyLen int
+}
+
+// The `Hash` method hashes the password with scrypt.
+func (s Scrypt) Hash(password string) (string, error) {
+	salt, err := newSalt()
+	if err != nil {
+		return "", err
+	}
+	key, err := scrypt.Key([]byte(password), salt, 1<<s.LogN, s.R, s.P, s.KeyLen)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("$%s$ln=%d,r=%d,p=%d$%s$%s", scryptID, s.LogN, s.R, s.P, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+func (s Scrypt) weakerThan(other Algorithm) bool {
+	o := other.(Scrypt)
+	return s.LogN < o.LogN || s.R < o.R || s.P < o.P || s.KeyLen < o.KeyLen
+}
+
+// The function `parseScrypt` reads the parameters, the salt and the key of an scrypt PHC string.
+func parseScrypt(encoded string) (Scrypt, []byte, []byte, error) {
+	version, params, salt, key, err := parsePHC(encoded, scryptID)
+	if err != nil || version != "" {
+		return Scrypt{}, nil, nil, MalformedHashError
+	}
+	return scryptParams(params["ln"], params["r"], params["p"], salt, key)
+}
+
+// The function `verifyScrypt` checks the password against an scrypt PHC string.
+func verifyScrypt(password string, encoded string) error {
+	s, salt, key, err := parseScrypt(encoded)
+	if err != nil {
+		return err
+	}
+	return compareScrypt(password, s, salt, key)
+}
+
+// The function `scryptParams` reads and bounds the scrypt parameters of a hash, so that a malformed
+// hash can not make the server allocate an unbounded amount of memory.
+func scryptParams(logN string, r string, p string, salt []byte, key []byte) (Scrypt, []byte, []byte, error) {
+	values := map[string]string{"ln": logN, "r": r, "p": p}
+	s := Scrypt{KeyLen: len(key)}
+	var err error
+	if s.LogN, err = intParam(values, "ln", 24); err != nil {
+		return Scrypt{}, nil, nil, err
+	}
+	if s.R, err = intParam(values, "r", 64); err != nil {
+		return Scrypt{}, nil, nil, err
+	}
+	if s.P, err = intParam(values, "p", 64); err != nil {
+		return Scrypt{}, nil, nil, err
+	}
+	return s, salt, key, nil
+}
+
+// The function `compareScrypt` derives the key of the password with the parameters and salt of a hash
+// and compares it with the key of the hash in constant time.
+func compareScrypt(password string, s Scrypt, salt []byte, key []byte) error {
+	derived, err := scrypt.Key([]byte(password), salt, 1<<s.LogN, s.R, s.P, len(key))
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(derived, key) != 1 {
+		return MismatchError
+	}
+	return nil
+}
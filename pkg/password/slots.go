@@ -0,0 +1,41 @@
+package password
+
+import (
+	"errors"
+	"time"
+)
+
+// The error returned by `Do` when all the slots are busy.
+var BusyError = errors.New("pass: too many passwords are being hashed, try again later")
+
+// The `Slots` struct bounds the number of passwords that are hashed or verified at the same time, as
+// every hash takes a lot of memory (128 * r * N bytes for scrypt), and the time a caller waits for a
+// free slot.
+type Slots struct {
+	slots chan struct{}
+	wait  time.Duration
+}
+
+// The function `NewSlots` returns the given number of slots, which are waited for at most `wait`.
+func NewSlots(concurrency int, wait time.Duration) *Slots {
+	return &Slots{slots: make(chan struct{}, concurrency), wait: wait}
+}
+
+// The `Do` method runs the function in a free slot. It waits at most for the wait of the slots and
+// returns `BusyError` if none becomes free, so that a burst of logins fails fast instead of exhausting
+// the memory of the server.
+func (s *Slots) Do(fn func() error) error {
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		timer := time.NewTimer(s.wait)
+		defer timer.Stop()
+		select {
+		case s.slots <- struct{}{}:
+		case <-timer.C:
+			return BusyError
+		}
+	}
+	defer func() { <-s.slots }()
+	return fn()
+}
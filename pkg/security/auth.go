@@ -1,22 +1,158 @@
 package security
 
 import (
+	"errors"
 	"time"
 
+	"coderero.dev/projects/go/gin/hello/cache"
 	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/tokenfamily"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+	"github.com/golang-jwt/jwt/v5"
 )
 
-// The function GenerateAuthTokens generates access and refresh tokens for a user.
-func GenerateAuthTokens(obj *models.User) (string, string) {
-	// The subject of the token is the email of the user.
-	sub := obj.Email
+// The error returned by `RotateRefreshToken` for a token that is not a refresh token.
+var NotRefreshTokenError = errors.New("jwt: only refresh tokens can be exchanged for new tokens")
 
-	// The current time is used to set the expiration time of the tokens.
-	currentTime := time.Now()
+// The function GenerateAuthTokens generates access and refresh tokens for a user that belong to the
+// given session. The refresh token starts a new refresh token family that has the ID of the session.
+func GenerateAuthTokens(obj *models.User, session string) (string, string) {
+	// The subject of the token is the UUID of the user, which never changes.
+	return generateTokenPair(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: obj.UUID},
+		Session:          session,
+	}, time.Now())
+}
+
+// The function `GenerateOAuthTokens` generates access and refresh tokens for a user that have been
+// granted to an OAuth client with the given scope. The tokens belong to the given session.
+func GenerateOAuthTokens(obj *models.User, session string, clientID string, scope string) (string, string) {
+	return generateTokenPair(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: obj.UUID},
+		Session:          session,
+		Scope:            scope,
+		ClientID:         clientID,
+	}, time.Now())
+}
+
+// The function `GenerateAccessToken` generates an access token for the given subject that belongs to
+// the given session.
+func GenerateAccessToken(sub string, session string, exp time.Time) string {
+	return GenerateTokenWithClaims(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   sub,
+			ExpiresAt: jwt.NewNumericDate(exp),
+		},
+		Session: session,
+	})
+}
+
+// The function `RotateRefreshToken` exchanges a refresh token for a new access token and a new refresh
+// token of the same family, issued for the given subject with the same session, scope and client as the
+// refresh token. Presenting a refresh token that has already been exchanged revokes the whole family and
+// returns `cache.TokenReusedError`. Any other token is rejected with `NotRefreshTokenError`.
+func RotateRefreshToken(refreshToken string, sub string) (string, string, error) {
+	jwtToken, err := VerifyToken(refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+	claims := jwtToken.Claims.(*Claims)
+	now := time.Now()
+	var expiresAt time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	if !tokenfamily.IsRefreshToken(claims.Family, claims.ID, claims.Session, expiresAt, now) {
+		return "", "", NotRefreshTokenError
+	}
+	if IsTokenRevokedForUser(claims) {
+		return "", "", RevokedTokenError
+	}
+	grant := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: sub},
+		Session:          claims.Session,
+		Scope:            claims.Scope,
+		ClientID:         claims.ClientID,
+	}
+
+	// Refresh tokens issued before token families existed do not carry a family. They are revoked and
+	// replaced by the first token of a new family.
+	if claims.Family == "" {
+		family, err := utils.RandomID(16)
+		if err != nil {
+			return "", "", err
+		}
+		cache.RevokeToken(refreshToken)
+		return generateAccessToken(grant, now), startTokenFamily(grant, family, now), nil
+	}
+
+	next := generateRefreshToken(grant, claims.Family, now)
+	if err := cache.RotateTokenFamily(claims.Family, refreshToken, next, TokenLifetimes.RefreshToken); err != nil {
+		return "", "", err
+	}
+
+	// The session is used as long as its refresh tokens are rotated, so it only expires together with
+	// its refresh token family (see `models.Session.IsExpired`).
+	if claims.Session != "" {
+		var session models.Session
+		if err := session.Touch(claims.Session, TokenLifetimes.RefreshToken); err != nil {
+			cache.RevokeTokenFamily(claims.Family)
+			return "", "", RevokedTokenError
+		}
+	}
+	return generateAccessToken(grant, now), next, nil
+}
+
+// The function `RevokeRefreshToken` revokes a refresh token together with its refresh token family and
+// ends the session it was issued for.
+func RevokeRefreshToken(refreshToken string) {
+	cache.RevokeToken(refreshToken)
+
+	jwtToken, err := VerifyToken(refreshToken)
+	if err != nil {
+		return
+	}
+	claims := jwtToken.Claims.(*Claims)
+	if claims.Family != "" {
+		cache.RevokeTokenFamily(claims.Family)
+	}
+	if claims.Session != "" {
+		RevokeSession(&models.Session{ID: claims.Session})
+	}
+}
+
+// The function `RevokeSession` deletes a session and revokes the refresh token family issued for it.
+// The access tokens of the session are rejected as soon as the session no longer exists.
+func RevokeSession(session *models.Session) error {
+	cache.RevokeTokenFamily(session.ID)
+	return session.Delete()
+}
+
+// The function `generateTokenPair` generates an access token and the first refresh token of a new
+// refresh token family for the given grant. The family has the ID of the session of the grant.
+func generateTokenPair(grant Claims, now time.Time) (string, string) {
+	return generateAccessToken(grant, now), startTokenFamily(grant, grant.Session, now)
+}
+
+// The function `generateAccessToken` generates an access token for the given grant.
+func generateAccessToken(grant Claims, now time.Time) string {
+	grant.ExpiresAt = jwt.NewNumericDate(TokenLifetimes.AccessTokenExpiresAt(now))
+	return GenerateTokenWithClaims(grant)
+}
+
+// The function `startTokenFamily` creates a new refresh token family and returns its first refresh
+// token.
+func startTokenFamily(grant Claims, family string, now time.Time) string {
+	refreshToken := generateRefreshToken(grant, family, now)
+	cache.StartTokenFamily(family, refreshToken, TokenLifetimes.RefreshToken)
+	return refreshToken
+}
 
-	// The access token expires in 5 minutes and the refresh token expires in 24 hours from the
-	// current time. Both tokens are signed with the secret key and given expiration times.
-	accessToken := GenerateToken(sub, currentTime.Add(time.Minute*5))
-	refreshToken := GenerateToken(sub, currentTime.Add(time.Hour*24))
-	return accessToken, refreshToken
+// The function `generateRefreshToken` generates a refresh token of the given family for the given
+// grant. The random ID every token gets makes sure that two rotations within the same second never
+// produce the same token.
+func generateRefreshToken(grant Claims, family string, now time.Time) string {
+	grant.ExpiresAt = jwt.NewNumericDate(TokenLifetimes.RefreshTokenExpiresAt(now))
+	grant.Family = family
+	return GenerateTokenWithClaims(grant)
 }
@@ -0,0 +1,57 @@
+package security
+
+import (
+	"errors"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// The error returned by `AuthenticateClient` if the client does not exist or the secret is wrong.
+var InvalidClientError = errors.New("oauth: client authentication failed")
+
+// The function `AuthenticateClient` returns the OAuth client with the given client ID. Confidential
+// clients have to present their secret, which is compared like a password; public clients must not
+// present one. It returns `HashingBusyError` if the secret can not be compared.
+func AuthenticateClient(clientID string, secret string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	if err := client.GetClient(clientID); err != nil {
+		return nil, InvalidClientError
+	}
+
+	if !client.IsConfidential() {
+		if secret != "" {
+			return nil, InvalidClientError
+		}
+		return &client, nil
+	}
+	if secret == "" {
+		return nil, InvalidClientError
+	}
+	match, err := ComparePassword(secret, client.Secret)
+	if err != nil {
+		return nil, err
+	}
+	if !match {
+		return nil, InvalidClientError
+	}
+	return &client, nil
+}
+
+// The function `GenerateClientToken` generates an access token for an OAuth client acting on its own
+// behalf (the client credentials grant). The subject of the token is the client ID and there is no
+// refresh token, the client simply requests a new access token.
+func GenerateClientToken(client *models.OAuthClient, scope string) string {
+	return generateAccessToken(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: client.ClientID},
+		Scope:            scope,
+		ClientID:         client.ClientID,
+	}, time.Now())
+}
+
+// The function `IsClientToken` reports if the claims are those of a token an OAuth client was issued
+// for itself, whose subject is the client instead of a user.
+func IsClientToken(claims *Claims) bool {
+	return claims.ClientID != "" && claims.Subject == claims.ClientID
+}
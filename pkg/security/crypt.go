@@ -1,13 +1,19 @@
 package security
 
 import (
-	"coderero.dev/projects/go/gin/hello/pkg/utils"
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/password"
 )
 
-// The function HashPassword takes a raw password as input and returns its hashed version using bcrypt
-// algorithm.
+// The function HashPassword takes a raw password as input and returns its hashed version using the
+// configured password hashing algorithm (see `PasswordHasher`) and, if configured, pepper. It returns
+// `HashingBusyError` if too many passwords are being hashed at the same time.
 func HashPassword(password_raw string) (string, error) {
-	hashed_password, err := utils.CreatePassword(password_raw)
+	var hashed_password string
+	err := hashSlots.Do(func() (err error) {
+		hashed_password, err = hashPassword(password_raw)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
@@ -15,11 +21,43 @@ func HashPassword(password_raw string) (string, error) {
 }
 
 // The function `ComparePassword` compares a raw password with a hashed password and returns true if
-// they match, and false otherwise.
-func ComparePassword(password_raw string, password_hashed string) bool {
-	err := utils.ComparePassword(password_raw, password_hashed)
+// they match, and false otherwise. Hashes of every supported algorithm and of the legacy format are
+// verified, and a malformed hash or a hash with an unknown pepper never matches. As a busy server can not
+// tell if the password matches, it returns `HashingBusyError` if too many passwords are being hashed at
+// the same time.
+func ComparePassword(password_raw string, password_hashed string) (bool, error) {
+	var err error
+	busy := hashSlots.Do(func() error {
+		err = password.VerifyWithPeppers(password_raw, password_hashed, peppers)
+		return nil
+	})
+	if busy != nil {
+		return false, busy
+	}
+	return err == nil, nil
+}
+
+// The function `PasswordNeedsRehash` reports if a password hash should be replaced with a hash of the
+// configured algorithm, parameters and pepper, because it is weaker, in an older format or peppered with
+// another pepper.
+func PasswordNeedsRehash(password_hashed string) bool {
+	if password.PepperID(password_hashed) != currentPepperID() {
+		return true
+	}
+	return password.NeedsRehash(password_hashed, PasswordHasher)
+}
+
+// The function `UpgradePasswordHash` replaces the password hash of the user with a hash of the
+// configured algorithm, parameters and pepper if the stored hash is weaker or has another pepper. It is
+// called with the password the user has just logged in with, as the hash can only be recomputed while
+// the password is known.
+func UpgradePasswordHash(user *models.User, password_raw string) error {
+	if !PasswordNeedsRehash(user.Password) {
+		return nil
+	}
+	hash, err := HashPassword(password_raw)
 	if err != nil {
-		return false
+		return err
 	}
-	return true
+	return user.SetPassword(hash)
 }
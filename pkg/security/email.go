@@ -0,0 +1,40 @@
+package security
+
+import (
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/models"
+)
+
+// The purpose and the lifetime of the links that verify the email address of a user.
+const (
+	emailVerificationPurpose  = "verify_email"
+	EmailVerificationLifetime = 24 * time.Hour
+)
+
+// The function `EmailVerificationToken` generates the token of the link that verifies the email address
+// of the user. The token is bound to the address, so that it is no longer valid once the user has
+// changed it.
+func EmailVerificationToken(user *models.User) string {
+	return GenerateLinkToken(emailVerificationPurpose, user.UUID, user.Email, EmailVerificationLifetime)
+}
+
+// The function `VerifyEmail` marks the email address the token was issued for as verified and returns
+// the user it belongs to.
+func VerifyEmail(token string) (*models.User, error) {
+	claims, err := VerifyLinkToken(token, emailVerificationPurpose)
+	if err != nil {
+		return nil, err
+	}
+	user, err := GetSubjectUser(claims.Subject)
+	if err != nil || !claims.BoundTo(user.Email) {
+		return nil, InvalidLinkTokenError
+	}
+
+	if !user.EmailVerified {
+		if err := user.SetEmailVerified(true); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
@@ -0,0 +1,51 @@
+package security
+
+import (
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/cache"
+	"coderero.dev/projects/go/gin/hello/models"
+)
+
+// The function `IntrospectToken` returns the claims of a token and reports if the token is active. A
+// token is active if its signature and registered claims are valid, it is not on the denylist, the
+// session it was issued for still exists and has not expired and, for refresh tokens, it is the current
+// token of its family. Unlike `VerifyToken`, it therefore takes the revocations stored in the cache into
+// account.
+func IntrospectToken(token string) (*Claims, bool) {
+	jwtToken, err := VerifyToken(token)
+	if err != nil || !jwtToken.Valid {
+		return nil, false
+	}
+	claims := jwtToken.Claims.(*Claims)
+
+	if cache.IsTokenRevoked(token) || IsTokenRevokedForUser(claims) {
+		return claims, false
+	}
+	if claims.Family != "" && !cache.IsTokenFamilyCurrent(claims.Family, token) {
+		return claims, false
+	}
+	if claims.Session != "" {
+		var session models.Session
+		if err := session.GetSessionByID(claims.Session); err != nil || session.IsExpired(TokenLifetimes.RefreshToken, time.Now()) {
+			return claims, false
+		}
+	}
+	return claims, true
+}
+
+// The function `RevokeToken` revokes a token issued by the server. Revoking a refresh token revokes its
+// family and ends its session, which also invalidates the access tokens of the session (RFC 7009,
+// section 2.1). Tokens that are invalid or have already expired are ignored.
+func RevokeToken(token string) {
+	jwtToken, err := VerifyToken(token)
+	if err != nil || !jwtToken.Valid {
+		return
+	}
+
+	if jwtToken.Claims.(*Claims).Family != "" {
+		RevokeRefreshToken(token)
+		return
+	}
+	cache.RevokeToken(token)
+}
@@ -0,0 +1,146 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// The `previousKeysDir` constant is the directory the public keys of previous signing keys are loaded
+// from. Tokens signed with these keys are still accepted, but no new tokens are signed with them.
+const previousKeysDir = "./certs/previous"
+
+// The `JSONWebKey` struct is the JSON representation of a public verification key (RFC 7517). Only the
+// members of the key type of the key are set.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// The `JSONWebKeySet` struct is the JSON representation of a set of public verification keys.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// The `verificationKey` struct holds a key tokens can be verified with together with the only signing
+// method a token verified with the key may use.
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+}
+
+// The `var` block is declaring the key ID of the active signing key and the keys tokens can be verified
+// with, indexed by their key ID. The `verificationKeyIDs` slice keeps the order the keys were loaded
+// in, the active key first.
+var (
+	signingKeyID       string
+	verificationKeys   = map[string]verificationKey{}
+	verificationKeyIDs []string
+)
+
+// The function `JWKS` returns the public keys tokens can be verified with, the active key first. HMAC
+// secrets are never published.
+func JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(verificationKeyIDs))}
+	for _, kid := range verificationKeyIDs {
+		if key := verificationKeys[kid]; isPublicKey(key.key) {
+			set.Keys = append(set.Keys, toJSONWebKey(kid, key))
+		}
+	}
+	return set
+}
+
+// The function `addVerificationKey` registers a key tokens signed with the given method can be verified
+// with and returns its key ID.
+func addVerificationKey(method jwt.SigningMethod, key crypto.PublicKey) string {
+	kid := keyID(key)
+	if _, ok := verificationKeys[kid]; !ok {
+		verificationKeys[kid] = verificationKey{method: method, key: key}
+		verificationKeyIDs = append(verificationKeyIDs, kid)
+	}
+	return kid
+}
+
+// The function `loadPreviousKeys` registers every PEM-encoded public key in the given directory as a
+// verification key. A missing directory means that the signing key has never been rotated.
+func loadPreviousKeys(dir string) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		panic(err)
+	}
+
+	for _, file := range files {
+		public, err := os.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		method, pub, err := parsePublicKey(public)
+		if err != nil {
+			panic(fmt.Errorf("%s: %w", file, err))
+		}
+		addVerificationKey(method, pub)
+	}
+}
+
+// The function `keyID` returns the JWK thumbprint (RFC 7638) of a key, which is used as its key ID. The
+// key ID therefore does not have to be configured and never changes for a given key.
+func keyID(key crypto.PublicKey) string {
+	var members string
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		jwk := toJSONWebKey("", verificationKey{key: k})
+		members = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+	case *ecdsa.PublicKey:
+		jwk := toJSONWebKey("", verificationKey{key: k})
+		members = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, jwk.Crv, jwk.X, jwk.Y)
+	case ed25519.PublicKey:
+		jwk := toJSONWebKey("", verificationKey{key: k})
+		members = fmt.Sprintf(`{"crv":"%s","kty":"OKP","x":"%s"}`, jwk.Crv, jwk.X)
+	case []byte:
+		members = fmt.Sprintf(`{"k":"%s","kty":"oct"}`, base64.RawURLEncoding.EncodeToString(k))
+	}
+	thumbprint := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(thumbprint[:])
+}
+
+// The function `toJSONWebKey` converts a public verification key to its JSON Web Key representation.
+func toJSONWebKey(kid string, key verificationKey) JSONWebKey {
+	jwk := JSONWebKey{Use: "sig", Kid: kid}
+	if key.method != nil {
+		jwk.Alg = key.method.Alg()
+	}
+
+	switch k := key.key.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = k.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(k)
+	}
+	return jwk
+}
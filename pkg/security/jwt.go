@@ -1,93 +1,205 @@
 package security
 
 import (
-	"crypto/rsa"
+	"crypto"
+	"fmt"
 	"net/http"
 	"os"
 	"time"
 
 	"coderero.dev/projects/go/gin/hello/cache"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
 	"coderero.dev/projects/go/gin/hello/types"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/joho/godotenv"
 )
 
-// The `var` block is declaring two variables `privateKey` and `publicKey` of type `*rsa.PrivateKey`
-// and `*rsa.PublicKey` respectively. These variables will be used to store the private and public keys
-// for generating and verifying JWT tokens.
+// The `var` block is declaring the signing method selected by the configuration and the private key
+// tokens are signed with. The key is an RSA, ECDSA or Ed25519 private key, or the HMAC secret.
 var (
-	privateKey *rsa.PrivateKey
-	publicKey  *rsa.PublicKey
+	signingMethod jwt.SigningMethod
+	signingKey    crypto.PrivateKey
 )
 
-// The `var` block is declaring two variables `AcessTokenExpireTime` and `RefreshTokenExpireTime` and
-// assigning them values using the `time.Now().Add()` function. These variables will be used to store
-// the expiration time for the access and refresh tokens.
+// The `var` block is declaring the issuer and the audience of the issued tokens and the clock skew
+// tolerated when the time based claims are verified. Tokens with another issuer or another audience
+// are rejected, so that a token minted for one service can not be replayed against another.
 var (
-	AcessTokenExpireTime   = time.Now().Add(15 * time.Minute)
-	RefreshTokenExpireTIme = time.Now().Add(7 * 24 * time.Hour)
+	issuer   string
+	audience string
+	leeway   time.Duration
 )
 
 func init() {
+	godotenv.Load()
 
-	// The code is reading the contents of two files, `private.key` and `public.pem`, located in the
-	// `./certs` directory. It uses the `os.ReadFile()` function to read the files and assigns the contents
-	// to the variables `private` and `public` respectively.
-	private, err := os.ReadFile("./certs/private.key")
+	// The signing algorithm is selected with the `JWT_ALGORITHM` environment variable (RS256, ES256,
+	// EdDSA or HS256) and defaults to RS256.
+	method, err := getSigningMethod(os.Getenv("JWT_ALGORITHM"))
 	if err != nil {
 		panic(err)
 	}
-	public, err1 := os.ReadFile("./certs/public.pem")
-	if err1 != nil {
-		panic(err1)
-	}
 
-	// The code block is parsing the private and public keys from the PEM-encoded files `private.key` and
-	// `public.pem` respectively.
-	key, err2 := jwt.ParseRSAPrivateKeyFromPEM(private)
-	if err2 != nil {
-		panic(err2)
+	// The code block is loading the key pair of the selected signing method.
+	key, pub, err := loadSigningKey(method)
+	if err != nil {
+		panic(err)
 	}
-	pub, err3 := jwt.ParseRSAPublicKeyFromPEM(public)
-	if err3 != nil {
-		panic(err3)
+	signingMethod, signingKey = method, key
+
+	// The active public key and the public keys of the previous signing keys are registered as
+	// verification keys, so that rotating the signing key does not invalidate the issued tokens. There
+	// are no previous keys for HMAC secrets, because they are never published.
+	signingKeyID = addVerificationKey(method, pub)
+	if isPublicKey(pub) {
+		loadPreviousKeys(previousKeysDir)
 	}
 
-	// The code block is assigning the parsed private and public keys to the variables `privateKey` and
-	privateKey, publicKey = key, pub
+	// The issuer, the audience and the leeway are read from the `JWT_ISSUER`, `JWT_AUDIENCE` and
+	// `JWT_LEEWAY` (e.g. "30s") environment variables. An empty issuer or audience is not enforced.
+	issuer, audience = os.Getenv("JWT_ISSUER"), os.Getenv("JWT_AUDIENCE")
+	if value := os.Getenv("JWT_LEEWAY"); value != "" {
+		leeway, err = time.ParseDuration(value)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
 
+// The `Claims` struct is used to store the claims of the JWT tokens issued by the server. The `Session`
+// field identifies the login session the token was issued for and the `Family` field, which is only set
+// on refresh tokens, identifies the refresh token family the token belongs to. The `Scope` and
+// `ClientID` fields hold the space separated scopes granted to the token and the OAuth client it was
+// issued to. The `Purpose` and `Binding` fields are only set on link tokens (see `GenerateLinkToken`) and
+// the `Type` field is only set on ID tokens (see `GenerateIDToken`).
+type Claims struct {
+	jwt.RegisteredClaims
+	Session  string `json:"sid,omitempty"`
+	Family   string `json:"fam,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	Purpose  string `json:"purpose,omitempty"`
+	Binding  string `json:"bnd,omitempty"`
+	Type     string `json:"typ,omitempty"`
 }
 
-// The function generates a JWT token with a specified subject and expiration time using the RS256
+// The function generates a JWT token with a specified subject and expiration time using the configured
 // signing method.
 func GenerateToken(sub string, exp time.Time) string {
-	// The `jwt.NewNumericDate()` function is used to convert the expiration time to a numeric date
-	// format.
-	expiresIn := jwt.NewNumericDate(exp)
-
 	// The `jwt.RegisteredClaims` struct is used to store the claims of the JWT token. The `Subject`
-	// field is used to store the subject of the token, which is the email of the user. The `ExpiresAt`
+	// field is used to store the subject of the token, which is the UUID of the user. The `ExpiresAt`
 	// field is used to store the expiration time of the token.
-	claims := jwt.RegisteredClaims{
-		Subject:   sub,
-		ExpiresAt: expiresIn,
+	return GenerateTokenWithClaims(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   sub,
+			ExpiresAt: jwt.NewNumericDate(exp),
+		},
+	})
+}
+
+// The function `GenerateTokenWithClaims` generates a JWT token carrying the given claims using the
+// configured signing method. The registered claims that are not set are filled in: a random ID, which
+// is used to revoke the token, the issue time, the issuer and the audience.
+func GenerateTokenWithClaims(claims Claims) string {
+	if claims.ID == "" {
+		id, err := utils.RandomID(16)
+		if err != nil {
+			panic(err)
+		}
+		claims.ID = id
+	}
+	if claims.IssuedAt == nil {
+		claims.IssuedAt = jwt.NewNumericDate(time.Now())
+	}
+	if claims.NotBefore == nil {
+		claims.NotBefore = claims.IssuedAt
 	}
+	if claims.Issuer == "" {
+		claims.Issuer = issuer
+	}
+	if len(claims.Audience) == 0 && audience != "" {
+		claims.Audience = jwt.ClaimStrings{audience}
+	}
+
+	return signToken(claims)
+}
 
+// The function `signToken` signs the given claims with the active signing key and names the key in the
+// `kid` header of the token.
+func signToken(claims jwt.Claims) string {
 	// The `jwt.NewWithClaims()` function is used to create a new JWT token with the specified claims
 	// and signing method.
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	signedToken, err := token.SignedString(privateKey)
+	token := jwt.NewWithClaims(signingMethod, claims)
+	token.Header["kid"] = signingKeyID
+	signedToken, err := token.SignedString(signingKey)
 	if err != nil {
 		panic(err)
 	}
 	return signedToken
 }
 
-// The function `VerifyToken` parses a JWT token using a provided public key.
+// The function `VerifyToken` parses a JWT token using the key named by the `kid` header of the token.
+// Tokens issued before key IDs existed are verified with the active key. The algorithm of the token has
+// to be the algorithm of the key, so that a token can never pick how it is verified (e.g. an HS256
+// token "signed" with a published RSA public key).
+// Link tokens and ID tokens are signed with the same keys but are never accepted as access or refresh
+// tokens.
 func VerifyToken(token string) (*jwt.Token, error) {
-	return jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		return publicKey, nil
-	})
+	jwtToken, err := parseToken(token)
+	if err == nil && jwtToken.Claims.(*Claims).Purpose != "" {
+		jwtToken.Valid = false
+		return jwtToken, LinkTokenError
+	}
+	if err == nil && jwtToken.Claims.(*Claims).Type != "" {
+		jwtToken.Valid = false
+		return jwtToken, IDTokenError
+	}
+	return jwtToken, err
+}
+
+// The function `parseToken` parses and verifies a JWT token of any kind.
+func parseToken(token string) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(token, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			kid = signingKeyID
+		}
+		key, ok := verificationKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("jwt: unknown key id %q", kid)
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("jwt: unexpected signing algorithm %q", token.Method.Alg())
+		}
+		return key.key, nil
+	}, parserOptions()...)
+}
+
+// The function `parserOptions` returns the options the tokens are verified with: the allowed signing
+// methods, the clock skew tolerance, the issue time check and, if configured, the issuer and audience.
+func parserOptions() []jwt.ParserOption {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(validMethods()),
+		jwt.WithLeeway(leeway),
+		jwt.WithIssuedAt(),
+	}
+	if issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+	return options
+}
+
+// The function `validMethods` returns the names of the signing methods of the verification keys.
+func validMethods() []string {
+	methods := make([]string, 0, len(verificationKeyIDs))
+	for _, kid := range verificationKeyIDs {
+		methods = append(methods, verificationKeys[kid].method.Alg())
+	}
+	return methods
 }
 
 // The function `IsTokenExpired` checks if a given JWT token is expired or not.
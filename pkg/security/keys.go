@@ -0,0 +1,113 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// The `minSecretLength` constant is the minimum length of the HMAC secret in bytes. RFC 7518 requires
+// the key of HS256 to be at least as long as the hash output.
+const minSecretLength = 32
+
+// The `signingMethods` map contains the signing algorithms that can be selected with the
+// `JWT_ALGORITHM` environment variable, indexed by their `alg` header value.
+var signingMethods = map[string]jwt.SigningMethod{
+	jwt.SigningMethodRS256.Alg(): jwt.SigningMethodRS256,
+	jwt.SigningMethodES256.Alg(): jwt.SigningMethodES256,
+	jwt.SigningMethodEdDSA.Alg(): jwt.SigningMethodEdDSA,
+	jwt.SigningMethodHS256.Alg(): jwt.SigningMethodHS256,
+}
+
+// The function `getSigningMethod` returns the signing method with the given `alg` name. An empty name
+// selects RS256, the algorithm the server has always used.
+func getSigningMethod(alg string) (jwt.SigningMethod, error) {
+	if alg == "" {
+		return jwt.SigningMethodRS256, nil
+	}
+	method, ok := signingMethods[alg]
+	if !ok {
+		return nil, fmt.Errorf("jwt: unsupported signing algorithm %q", alg)
+	}
+	return method, nil
+}
+
+// The function `loadSigningKey` loads the key pair of the given signing method. The asymmetric key pairs
+// are read from `private.key` and `public.pem` in the `./certs` directory, the HMAC secret is read from
+// the `JWT_SECRET` environment variable and is used both for signing and verifying.
+func loadSigningKey(method jwt.SigningMethod) (crypto.PrivateKey, crypto.PublicKey, error) {
+	if method == jwt.SigningMethodHS256 {
+		secret := []byte(os.Getenv("JWT_SECRET"))
+		if len(secret) < minSecretLength {
+			return nil, nil, fmt.Errorf("jwt: JWT_SECRET must be at least %d bytes long", minSecretLength)
+		}
+		return secret, secret, nil
+	}
+
+	// The code is reading the contents of two files, `private.key` and `public.pem`, located in the
+	// `./certs` directory.
+	private, err := os.ReadFile("./certs/private.key")
+	if err != nil {
+		return nil, nil, err
+	}
+	public, err := os.ReadFile("./certs/public.pem")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var key crypto.PrivateKey
+	switch method {
+	case jwt.SigningMethodRS256:
+		key, err = jwt.ParseRSAPrivateKeyFromPEM(private)
+	case jwt.SigningMethodES256:
+		key, err = jwt.ParseECPrivateKeyFromPEM(private)
+	case jwt.SigningMethodEdDSA:
+		key, err = jwt.ParseEdPrivateKeyFromPEM(private)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pubMethod, pub, err := parsePublicKey(public)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pubMethod != method {
+		return nil, nil, fmt.Errorf("jwt: public.pem is a %s key, but the signing algorithm is %s", pubMethod.Alg(), method.Alg())
+	}
+	return key, pub, nil
+}
+
+// The function `parsePublicKey` parses a PEM-encoded RSA, ECDSA P-256 or Ed25519 public key and returns
+// the only signing method tokens may be verified with using that key.
+func parsePublicKey(public []byte) (jwt.SigningMethod, crypto.PublicKey, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(public); err == nil {
+		return jwt.SigningMethodRS256, key, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM(public); err == nil {
+		if key.Curve != elliptic.P256() {
+			return nil, nil, fmt.Errorf("jwt: only P-256 ECDSA keys are supported")
+		}
+		return jwt.SigningMethodES256, key, nil
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM(public); err == nil {
+		return jwt.SigningMethodEdDSA, key, nil
+	}
+	return nil, nil, fmt.Errorf("jwt: unsupported public key, expected an RSA, ECDSA P-256 or Ed25519 key")
+}
+
+// The function `isPublicKey` reports if a verification key may be published, which is not the case for
+// HMAC secrets.
+func isPublicKey(key crypto.PublicKey) bool {
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package security
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// The `TokenLifetimePolicy` struct holds the lifetimes of the access and refresh tokens. The same
+// lifetimes are used for the `exp` claim of the tokens and for the max age of the cookies they are
+// stored in, so that a cookie never outlives its token or the other way around.
+type TokenLifetimePolicy struct {
+	AccessToken  time.Duration
+	RefreshToken time.Duration
+}
+
+// The `TokenLifetimes` variable is the token lifetime policy of the deployment. The defaults are
+// overridden with the `ACCESS_TOKEN_LIFETIME` and `REFRESH_TOKEN_LIFETIME` environment variables
+// (e.g. "15m" and "168h").
+var TokenLifetimes = TokenLifetimePolicy{
+	AccessToken:  5 * time.Minute,
+	RefreshToken: 24 * time.Hour,
+}
+
+func init() {
+	policy, err := loadTokenLifetimes(TokenLifetimes)
+	if err != nil {
+		panic(err)
+	}
+	TokenLifetimes = policy
+}
+
+// The function `AccessTokenExpiresAt` returns the expiration time of an access token issued at `now`.
+func (p TokenLifetimePolicy) AccessTokenExpiresAt(now time.Time) time.Time {
+	return now.Add(p.AccessToken)
+}
+
+// The function `RefreshTokenExpiresAt` returns the expiration time of a refresh token issued at `now`.
+func (p TokenLifetimePolicy) RefreshTokenExpiresAt(now time.Time) time.Time {
+	return now.Add(p.RefreshToken)
+}
+
+// The function `AccessTokenMaxAge` returns the max age in seconds of the cookie the access token is
+// stored in.
+func (p TokenLifetimePolicy) AccessTokenMaxAge() int {
+	return int(p.AccessToken.Seconds())
+}
+
+// The function `RefreshTokenMaxAge` returns the max age in seconds of the cookie the refresh token is
+// stored in.
+func (p TokenLifetimePolicy) RefreshTokenMaxAge() int {
+	return int(p.RefreshToken.Seconds())
+}
+
+// The function `loadTokenLifetimes` overrides the lifetimes of the given policy with the ones
+// configured in the environment and validates the result.
+func loadTokenLifetimes(policy TokenLifetimePolicy) (TokenLifetimePolicy, error) {
+	for name, lifetime := range map[string]*time.Duration{
+		"ACCESS_TOKEN_LIFETIME":  &policy.AccessToken,
+		"REFRESH_TOKEN_LIFETIME": &policy.RefreshToken,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return policy, fmt.Errorf("%s: %w", name, err)
+		}
+		*lifetime = duration
+	}
+
+	if policy.AccessToken < time.Second || policy.RefreshToken < time.Second {
+		return policy, fmt.Errorf("token lifetimes must be at least one second")
+	}
+	if policy.RefreshToken < policy.AccessToken {
+		return policy, fmt.Errorf("the refresh token lifetime must not be shorter than the access token lifetime")
+	}
+	return policy, nil
+}
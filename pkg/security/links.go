@@ -0,0 +1,68 @@
+package security
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// The errors returned for link tokens. `LinkTokenError` is returned by `VerifyToken` for a link token
+// presented as an access or refresh token.
+var (
+	LinkTokenError        = errors.New("jwt: link tokens can not be used as access or refresh tokens")
+	InvalidLinkTokenError = errors.New("jwt: invalid or expired link")
+)
+
+// The function `GenerateLinkToken` generates a signed, expiring token for a link that is sent to the
+// user, e.g. to verify the email address. The purpose of the token is part of the signed claims, so
+// that a token can only be used for what it was issued for. The token is bound to a value the link is
+// only valid for (e.g. the email address it was sent to); only the digest of the value is part of the
+// token.
+func GenerateLinkToken(purpose string, sub string, bindTo string, lifetime time.Duration) string {
+	return generateLinkToken("", purpose, sub, bindTo, lifetime)
+}
+
+// The function `generateLinkToken` generates a link token with the given ID, which is random if it is
+// empty.
+func generateLinkToken(id string, purpose string, sub string, bindTo string, lifetime time.Duration) string {
+	return GenerateTokenWithClaims(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Subject:   sub,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(lifetime)),
+		},
+		Purpose: purpose,
+		Binding: LinkBinding(bindTo),
+	})
+}
+
+// The function `VerifyLinkToken` verifies a link token issued for the given purpose and returns its
+// claims. The caller checks the binding of the token with `BoundTo`.
+func VerifyLinkToken(token string, purpose string) (*Claims, error) {
+	jwtToken, err := parseToken(token)
+	if err != nil || !jwtToken.Valid {
+		return nil, InvalidLinkTokenError
+	}
+
+	claims := jwtToken.Claims.(*Claims)
+	if claims.Purpose != purpose {
+		return nil, InvalidLinkTokenError
+	}
+	return claims, nil
+}
+
+// The `BoundTo` method reports if the link token the claims belong to is bound to the given value.
+func (c *Claims) BoundTo(value string) bool {
+	return subtle.ConstantTimeCompare([]byte(c.Binding), []byte(LinkBinding(value))) == 1
+}
+
+// The function `LinkBinding` returns the digest of a value a link token is bound to, so that the value
+// itself is not part of the link.
+func LinkBinding(value string) string {
+	digest := sha256.Sum256([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
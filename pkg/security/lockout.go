@@ -0,0 +1,185 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/cache"
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/lockout"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+)
+
+// The `Lockout` variable is the lockout policy of the deployment (see `lockout.Policy`). The defaults
+// are overridden with the `LOGIN_LOCKOUT_THRESHOLD` (0 disables the lockout), `LOGIN_LOCKOUT_DURATION`,
+// `LOGIN_BACKOFF_BASE` and `LOGIN_BACKOFF_MAX` environment variables.
+var Lockout = lockout.Policy{
+	Threshold:   10,
+	Duration:    15 * time.Minute,
+	BackoffBase: time.Second,
+	BackoffMax:  30 * time.Second,
+}
+
+// The purpose of the links that unlock a locked account.
+const unlockAccountPurpose = "unlock_account"
+
+// The `loginAttemptTimeout` constant is the time after which the reservation of a login attempt ends if
+// the attempt is never finished, e.g. because the server stopped.
+const loginAttemptTimeout = 30 * time.Second
+
+// The errors returned by `ReserveLoginAttempt`.
+var (
+	AccountLockedError = errors.New("lockout: the account is locked")
+	LoginBackoffError  = errors.New("lockout: too many failed logins, wait before trying again")
+)
+
+func init() {
+	policy, err := loadLockoutPolicy(Lockout)
+	if err != nil {
+		panic(err)
+	}
+	Lockout = policy
+}
+
+// The function `ReserveLoginAttempt` checks if the user may try to log in with a password or a second
+// factor and reserves the attempt, so that concurrent attempts can not all pass before the first failure
+// is recorded. It returns the ID of the attempt, which has to be passed to `LoginFailed` or
+// `ReleaseLoginAttempt`, or `AccountLockedError` or `LoginBackoffError` and the time until the next
+// attempt is allowed.
+func ReserveLoginAttempt(user *models.User) (string, time.Duration, error) {
+	attempt, err := utils.RandomID(16)
+	if err != nil {
+		return "", 0, err
+	}
+	reserved, err := cache.ReserveLoginAttempt(user.UUID, attempt, loginAttemptTimeout)
+	if err != nil {
+		return "", 0, err
+	}
+	if !reserved {
+		if id, ttl := cache.AccountLock(user.UUID); id != "" {
+			return "", ttl, AccountLockedError
+		}
+		return "", max(cache.LoginBackoff(user.UUID), time.Second), LoginBackoffError
+	}
+
+	// The lockout is checked once the attempt is reserved, so that a lockout set by the attempt that
+	// held the reservation before is always seen.
+	if id, ttl := cache.AccountLock(user.UUID); id != "" {
+		cache.ReleaseLoginAttempt(user.UUID, attempt)
+		return "", ttl, AccountLockedError
+	}
+	return attempt, 0, nil
+}
+
+// The function `ReleaseLoginAttempt` ends the reservation of a login attempt that has not failed.
+func ReleaseLoginAttempt(user *models.User, attempt string) error {
+	return cache.ReleaseLoginAttempt(user.UUID, attempt)
+}
+
+// The function `LoginFailed` records the failure of a login attempt of the user and replaces its
+// reservation with the back-off before the next attempt. It reports if the account has been locked by
+// this failure.
+func LoginFailed(user *models.User, attempt string) (bool, error) {
+	failures, err := cache.RecordLoginFailure(user.UUID, Lockout.Duration)
+	if err != nil {
+		cache.ReleaseLoginAttempt(user.UUID, attempt)
+		return false, err
+	}
+
+	if Lockout.Threshold > 0 && failures >= Lockout.Threshold {
+		id, err := utils.RandomID(16)
+		if err != nil {
+			cache.ReleaseLoginAttempt(user.UUID, attempt)
+			return false, err
+		}
+		if err := cache.LockAccount(user.UUID, id, Lockout.Duration); err != nil {
+			cache.ReleaseLoginAttempt(user.UUID, attempt)
+			return false, err
+		}
+		return true, cache.ReleaseLoginAttempt(user.UUID, attempt)
+	}
+	if delay := Lockout.Backoff(failures); delay > 0 {
+		return false, cache.SetLoginBackoff(user.UUID, delay)
+	}
+	return false, cache.ReleaseLoginAttempt(user.UUID, attempt)
+}
+
+// The function `LoginSucceeded` forgets the failed logins of the user.
+func LoginSucceeded(user *models.User) error {
+	return cache.ClearLoginFailures(user.UUID)
+}
+
+// The function `UnlockAccount` lifts the lockout of the account of the user and forgets the failed
+// logins.
+func UnlockAccount(user *models.User) error {
+	return cache.ClearLoginFailures(user.UUID)
+}
+
+// The function `AccountUnlockToken` generates the token of the link that lets the user lift the
+// lockout of the account. The token is bound to the current lockout, so that it does not lift a later
+// one. It returns an empty token if the account is not locked.
+func AccountUnlockToken(user *models.User) string {
+	id, ttl := cache.AccountLock(user.UUID)
+	if id == "" {
+		return ""
+	}
+	return GenerateLinkToken(unlockAccountPurpose, user.UUID, id, ttl)
+}
+
+// The function `UnlockAccountWithToken` lifts the lockout an unlock token was issued for and returns
+// the user it belongs to.
+func UnlockAccountWithToken(token string) (*models.User, error) {
+	claims, err := VerifyLinkToken(token, unlockAccountPurpose)
+	if err != nil {
+		return nil, err
+	}
+	user, err := GetSubjectUser(claims.Subject)
+	if err != nil {
+		return nil, InvalidLinkTokenError
+	}
+	if id, _ := cache.AccountLock(user.UUID); id == "" || !claims.BoundTo(id) {
+		return nil, InvalidLinkTokenError
+	}
+	return user, UnlockAccount(user)
+}
+
+// The function `loadLockoutPolicy` overrides the limits of the given policy with the ones configured in
+// the environment and validates the result.
+func loadLockoutPolicy(policy lockout.Policy) (lockout.Policy, error) {
+	if value := os.Getenv("LOGIN_LOCKOUT_THRESHOLD"); value != "" {
+		threshold, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return policy, fmt.Errorf("LOGIN_LOCKOUT_THRESHOLD: %w", err)
+		}
+		policy.Threshold = threshold
+	}
+	for name, duration := range map[string]*time.Duration{
+		"LOGIN_LOCKOUT_DURATION": &policy.Duration,
+		"LOGIN_BACKOFF_BASE":     &policy.BackoffBase,
+		"LOGIN_BACKOFF_MAX":      &policy.BackoffMax,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return policy, fmt.Errorf("%s: %w", name, err)
+		}
+		*duration = parsed
+	}
+
+	if policy.Threshold < 0 {
+		return policy, fmt.Errorf("the lockout threshold must not be negative")
+	}
+	if policy.Duration < time.Second {
+		return policy, fmt.Errorf("the lockout duration must be at least one second")
+	}
+	if policy.BackoffBase < 0 || policy.BackoffMax < policy.BackoffBase {
+		return policy, fmt.Errorf("the maximum login back-off must not be shorter than the base back-off")
+	}
+	return policy, nil
+}
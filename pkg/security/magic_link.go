@@ -0,0 +1,73 @@
+package security
+
+import (
+	"errors"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/cache"
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+)
+
+// The purpose and the lifetime of the magic sign-in links.
+const (
+	magicLinkPurpose  = "magic_link"
+	MagicLinkLifetime = 10 * time.Minute
+)
+
+// The errors returned by `ConsumeMagicLink`. `MagicLinkBrowserError` is returned for a link that is
+// opened in another browser than the one it was requested from.
+var (
+	InvalidMagicLinkError = errors.New("magic link: invalid, expired or already used link")
+	MagicLinkBrowserError = errors.New("magic link: the link was requested from another browser")
+)
+
+// The function `NewMagicLinkNonce` generates the random value a magic link is bound to. The value is
+// kept in a cookie of the browser that requests the link.
+func NewMagicLinkNonce() (string, error) {
+	return utils.RandomID(32)
+}
+
+// The function `IssueMagicLinkToken` issues the signed token of a magic link for the user, bound to the
+// nonce of the browser that requested it. The link can only be used once.
+func IssueMagicLinkToken(user *models.User, nonce string) (string, error) {
+	id, err := utils.RandomID(16)
+	if err != nil {
+		return "", err
+	}
+	if err := cache.StoreMagicLink(id, user.UUID, MagicLinkLifetime); err != nil {
+		return "", err
+	}
+	return generateLinkToken(id, magicLinkPurpose, user.UUID, nonce, MagicLinkLifetime), nil
+}
+
+// The function `ConsumeMagicLink` verifies the token of a magic link opened in the browser with the
+// given nonce and returns the user it was issued for. The link is used up, and as it was sent to the
+// email address of the user, the address is verified as well.
+func ConsumeMagicLink(token string, nonce string) (*models.User, error) {
+	claims, err := VerifyLinkToken(token, magicLinkPurpose)
+	if err != nil {
+		return nil, InvalidMagicLinkError
+	}
+	// The browser is checked before the link is used up, so that a forwarded link that is opened
+	// elsewhere can still be used by the user.
+	if nonce == "" || !claims.BoundTo(nonce) {
+		return nil, MagicLinkBrowserError
+	}
+
+	subject, err := cache.ConsumeMagicLink(claims.ID)
+	if err != nil || subject != claims.Subject {
+		return nil, InvalidMagicLinkError
+	}
+	user, err := GetSubjectUser(subject)
+	if err != nil {
+		return nil, InvalidMagicLinkError
+	}
+
+	if !user.EmailVerified {
+		if err := user.SetEmailVerified(true); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
@@ -0,0 +1,65 @@
+package security
+
+import (
+	"errors"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/cache"
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+)
+
+// The `mfaChallengeLifetime` constant is the time the user has to complete the second factor after the
+// password has been checked, and `mfaChallengeAttempts` is the number of wrong codes after which the
+// user has to log in again.
+const (
+	mfaChallengeLifetime = 5 * time.Minute
+	mfaChallengeAttempts = 5
+)
+
+// The error returned by `GetMFAChallengeUser` and `CompleteMFAChallenge` for a challenge token that is
+// unknown, has expired or has already been completed.
+var InvalidMFAChallengeError = errors.New("mfa: invalid or expired challenge")
+
+// The function `IsMFAEnabled` reports if the user has to complete a second factor to log in.
+func IsMFAEnabled(user *models.User) bool {
+	return user.TOTPEnabled
+}
+
+// The function `IssueMFAChallenge` issues a short lived challenge token for a user whose password has
+// been checked. The token is exchanged for the access and refresh tokens once the second factor has
+// been completed; it is not a JWT and can not be used to call the API.
+func IssueMFAChallenge(user *models.User) (string, error) {
+	token, err := utils.RandomID(32)
+	if err != nil {
+		return "", err
+	}
+	if err := cache.StoreMFAChallenge(token, user.UUID, mfaChallengeLifetime); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// The function `GetMFAChallengeUser` returns the user an MFA challenge token was issued for.
+func GetMFAChallengeUser(token string) (*models.User, error) {
+	subject, err := cache.GetMFAChallenge(token)
+	if err != nil {
+		return nil, InvalidMFAChallengeError
+	}
+	return GetSubjectUser(subject)
+}
+
+// The function `CompleteMFAChallenge` ends an MFA challenge whose second factor has been completed. It
+// fails if the challenge has been completed in the meantime.
+func CompleteMFAChallenge(token string) error {
+	if _, err := cache.ConsumeMFAChallenge(token); err != nil {
+		return InvalidMFAChallengeError
+	}
+	return nil
+}
+
+// The function `FailMFAChallenge` records a wrong second factor for an MFA challenge. It returns true
+// if the challenge has been ended because of too many wrong attempts.
+func FailMFAChallenge(token string) bool {
+	return cache.FailMFAChallenge(token, mfaChallengeAttempts) >= mfaChallengeAttempts
+}
@@ -0,0 +1,67 @@
+package security
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/cache"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// The `authorizationCodeLifetime` constant is the lifetime of an authorization code. The client
+// redeems the code right after the redirect, so the code only has to live for a short time.
+const authorizationCodeLifetime = time.Minute
+
+// The error returned by `RedeemAuthorizationCode` for a code that is unknown, has expired or has already
+// been redeemed.
+var InvalidAuthorizationCodeError = errors.New("oauth: invalid or expired authorization code")
+
+// The `AuthorizationCode` struct holds the grant an authorization code stands for. The `RedirectURI`
+// field is the redirect URI of the authorization request, which is empty if the request did not
+// include one; the token request has to include the same value. The `Nonce` field is the nonce of an
+// OpenID Connect request, which is passed on to the ID token.
+type AuthorizationCode struct {
+	ClientID      string `json:"client_id"`
+	RedirectURI   string `json:"redirect_uri"`
+	Subject       string `json:"sub"`
+	Scope         string `json:"scope,omitempty"`
+	CodeChallenge string `json:"code_challenge"`
+	Nonce         string `json:"nonce,omitempty"`
+}
+
+// The function `IssueAuthorizationCode` issues a random authorization code for the given grant and
+// stores the grant until the code expires.
+func IssueAuthorizationCode(grant AuthorizationCode) (string, error) {
+	code, err := utils.RandomID(32)
+	if err != nil {
+		return "", err
+	}
+	value, err := json.Marshal(grant)
+	if err != nil {
+		return "", err
+	}
+	if err := cache.StoreAuthorizationCode(code, value, authorizationCodeLifetime); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// The function `RedeemAuthorizationCode` returns the grant an authorization code stands for. A code
+// can only be redeemed once.
+func RedeemAuthorizationCode(code string) (*AuthorizationCode, error) {
+	value, err := cache.ConsumeAuthorizationCode(code)
+	if errors.Is(err, redis.Nil) {
+		return nil, InvalidAuthorizationCodeError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var grant AuthorizationCode
+	if err := json.Unmarshal(value, &grant); err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
@@ -0,0 +1,53 @@
+package security
+
+import (
+	"errors"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// The `IDTokenClaims` struct holds the claims of an OpenID Connect ID token. The audience of an ID token
+// is the client it was issued to. The `Type` field marks the token as an ID token, so that it is never
+// accepted as an access token, even if no audience is enforced.
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce string `json:"nonce,omitempty"`
+	Type  string `json:"typ"`
+}
+
+// The `idTokenType` constant is the value of the `typ` claim of the ID tokens.
+const idTokenType = "id_token"
+
+// The `IDTokenError` error is returned by `VerifyToken` for an ID token.
+var IDTokenError = errors.New("jwt: ID tokens can not be used as access or refresh tokens")
+
+// The function `Issuer` returns the configured issuer of the tokens, which is empty if the
+// `JWT_ISSUER` environment variable is not set.
+func Issuer() string {
+	return issuer
+}
+
+// The function `SigningAlgorithm` returns the name of the algorithm the tokens are signed with.
+func SigningAlgorithm() string {
+	return signingMethod.Alg()
+}
+
+// The function `GenerateIDToken` generates an OpenID Connect ID token for the user, issued by `iss` to
+// the given client. The nonce of the authentication request is included, so that the client can bind
+// the token to the request. The token expires with the access token issued with it.
+func GenerateIDToken(user *models.User, iss string, clientID string, nonce string) string {
+	now := time.Now()
+	return signToken(IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    iss,
+			Subject:   user.UUID,
+			Audience:  jwt.ClaimStrings{clientID},
+			ExpiresAt: jwt.NewNumericDate(TokenLifetimes.AccessTokenExpiresAt(now)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		Nonce: nonce,
+		Type:  idTokenType,
+	})
+}
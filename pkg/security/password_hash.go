@@ -0,0 +1,91 @@
+package security
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"coderero.dev/projects/go/gin/hello/pkg/password"
+)
+
+// The `PasswordHasher` variable is the algorithm new passwords are hashed with. It is selected with the
+// `PASSWORD_HASH_ALGORITHM` environment variable (scrypt, argon2id or bcrypt) and defaults to scrypt.
+// The parameters are overridden with the `PASSWORD_SCRYPT_LN`, `PASSWORD_SCRYPT_R`, `PASSWORD_SCRYPT_P`
+// and `PASSWORD_SCRYPT_KEYLEN`, the `PASSWORD_ARGON2_MEMORY` (KiB), `PASSWORD_ARGON2_TIME`,
+// `PASSWORD_ARGON2_THREADS` and `PASSWORD_ARGON2_KEYLEN`, or the `PASSWORD_BCRYPT_COST` environment
+// variables. The hashes of the users are upgraded when they log in.
+var PasswordHasher password.Algorithm = defaultScrypt
+
+// The default parameters of the password hashing algorithms. The scrypt default keeps the cost of the
+// legacy hashes (N = 2^14), so that the memory every hash takes does not grow with the new format.
+var (
+	defaultScrypt   = password.Scrypt{LogN: 14, R: 8, P: 1, KeyLen: 32}
+	defaultArgon2id = password.Argon2id{Memory: 19 * 1024, Time: 2, Threads: 1, KeyLen: 32}
+	defaultBcrypt   = password.Bcrypt{Cost: 12}
+)
+
+func init() {
+	hasher, err := loadPasswordHasher(os.Getenv("PASSWORD_HASH_ALGORITHM"))
+	if err != nil {
+		panic(err)
+	}
+	PasswordHasher = hasher
+}
+
+// The function `loadPasswordHasher` returns the password hashing algorithm with the given name and the
+// parameters configured in the environment.
+func loadPasswordHasher(name string) (password.Algorithm, error) {
+	switch name {
+	case "", "scrypt":
+		s := defaultScrypt
+		err := intsFromEnv(map[string]*int{
+			"PASSWORD_SCRYPT_LN":     &s.LogN,
+			"PASSWORD_SCRYPT_R":      &s.R,
+			"PASSWORD_SCRYPT_P":      &s.P,
+			"PASSWORD_SCRYPT_KEYLEN": &s.KeyLen,
+		})
+		if err == nil && (s.LogN < 10 || s.LogN > 24 || s.R < 1 || s.P < 1 || s.KeyLen < 16) {
+			err = fmt.Errorf("the scrypt parameters must be ln 10 to 24, r and p at least 1 and a key of at least 16 bytes")
+		}
+		return s, err
+	case "argon2id":
+		a := defaultArgon2id
+		memory, time, threads, keyLen := int(a.Memory), int(a.Time), int(a.Threads), int(a.KeyLen)
+		err := intsFromEnv(map[string]*int{
+			"PASSWORD_ARGON2_MEMORY":  &memory,
+			"PASSWORD_ARGON2_TIME":    &time,
+			"PASSWORD_ARGON2_THREADS": &threads,
+			"PASSWORD_ARGON2_KEYLEN":  &keyLen,
+		})
+		if err == nil && (memory < 8*threads || memory > 4<<20 || time < 1 || threads < 1 || threads > 255 || keyLen < 16) {
+			err = fmt.Errorf("the argon2id parameters must be at most 4 GiB and at least 8 KiB of memory per thread, 1 to 255 threads, a time of at least 1 and a key of at least 16 bytes")
+		}
+		a.Memory, a.Time, a.Threads, a.KeyLen = uint32(memory), uint32(time), uint8(threads), uint32(keyLen)
+		return a, err
+	case "bcrypt":
+		b := defaultBcrypt
+		err := intsFromEnv(map[string]*int{"PASSWORD_BCRYPT_COST": &b.Cost})
+		if err == nil && (b.Cost < 10 || b.Cost > 31) {
+			err = fmt.Errorf("the bcrypt cost must be between 10 and 31")
+		}
+		return b, err
+	}
+	return nil, fmt.Errorf("PASSWORD_HASH_ALGORITHM: unsupported algorithm %q", name)
+}
+
+// The function `intsFromEnv` overrides the given values with the integers of the environment variables
+// they are named after, if set.
+func intsFromEnv(values map[string]*int) error {
+	for name, value := range values {
+		env := os.Getenv(name)
+		if env == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(env)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*value = parsed
+	}
+	return nil
+}
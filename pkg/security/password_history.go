@@ -0,0 +1,55 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"coderero.dev/projects/go/gin/hello/models"
+)
+
+// The `PasswordHistory` variable is the number of previous passwords of a user that can not be used
+// again, in addition to the current one. It is overridden with the `PASSWORD_HISTORY` environment
+// variable, and 0 only rejects the current password.
+var PasswordHistory = 5
+
+// The error returned for a new password that is the current password or one of the previous ones.
+var PasswordReusedError = errors.New("pass: the password has been used before")
+
+func init() {
+	if value := os.Getenv("PASSWORD_HISTORY"); value != "" {
+		depth, err := strconv.Atoi(value)
+		if err != nil || depth < 0 {
+			panic(fmt.Errorf("PASSWORD_HISTORY: must be a non-negative number, got %q", value))
+		}
+		PasswordHistory = depth
+	}
+}
+
+// The function `IsPasswordReused` reports if the password is the current password of the user or one
+// of the last `PasswordHistory` passwords. It returns `HashingBusyError` if the passwords can not be
+// compared.
+func IsPasswordReused(user *models.User, password string) (bool, error) {
+	hashes := []string{user.Password}
+	if PasswordHistory > 0 {
+		used := user.GetUsedPasswords()
+		for i := 0; i < len(used) && i < PasswordHistory; i++ {
+			hashes = append(hashes, used[i].Password)
+		}
+	}
+
+	for _, hash := range hashes {
+		match, err := ComparePassword(password, hash)
+		if err != nil || match {
+			return match, err
+		}
+	}
+	return false, nil
+}
+
+// The function `ChangePassword` replaces the password hash of the user and keeps the previous hash in
+// the password history, forgetting the hashes beyond the last `PasswordHistory` passwords.
+func ChangePassword(user *models.User, hash string) error {
+	return user.ChangePassword(hash, PasswordHistory)
+}
@@ -0,0 +1,54 @@
+package security
+
+import (
+	"fmt"
+	"os"
+
+	"coderero.dev/projects/go/gin/hello/pkg/password"
+)
+
+// The `var` block is declaring the pepper new passwords are peppered with, which is nil if passwords
+// are not peppered, and the peppers hashes are verified with, by key ID. The peppers are read from the
+// file named by the `PASSWORD_PEPPER_FILE` environment variable, or from the `PASSWORD_PEPPERS`
+// environment variable, as a list of `<key ID>:<base64 key>` entries separated by newlines, commas or
+// spaces. The first entry is the current pepper; the others are previous peppers that are still
+// accepted, so that the hashes are upgraded to the current pepper when the users log in.
+var (
+	currentPepper *password.Pepper
+	peppers       = map[string]password.Pepper{}
+)
+
+func init() {
+	list := os.Getenv("PASSWORD_PEPPERS")
+	if file := os.Getenv("PASSWORD_PEPPER_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			panic(fmt.Errorf("PASSWORD_PEPPER_FILE: %w", err))
+		}
+		list = string(data)
+	}
+
+	current, all, err := password.ParsePeppers(list)
+	if err != nil {
+		panic(err)
+	}
+	currentPepper, peppers = current, all
+}
+
+// The function `hashPassword` hashes the password with the configured algorithm, peppered with the
+// current pepper if there is one.
+func hashPassword(pw string) (string, error) {
+	if currentPepper == nil {
+		return PasswordHasher.Hash(pw)
+	}
+	return currentPepper.Hash(PasswordHasher, pw)
+}
+
+// The function `currentPepperID` returns the key ID of the current pepper, or an empty string if
+// passwords are not peppered.
+func currentPepperID() string {
+	if currentPepper == nil {
+		return ""
+	}
+	return currentPepper.ID
+}
@@ -0,0 +1,84 @@
+package security
+
+import (
+	"fmt"
+	"os"
+
+	"coderero.dev/projects/go/gin/hello/pkg/password"
+	"coderero.dev/projects/go/gin/hello/types"
+)
+
+// The `PasswordPolicy` variable is the password policy of the deployment. The defaults are overridden
+// with the `PASSWORD_MIN_LENGTH`, `PASSWORD_MAX_LENGTH` and `PASSWORD_MIN_CLASSES` environment
+// variables, and the breached password list is loaded from the directory named by
+// `PASSWORD_BREACHED_DIR`, if set.
+var PasswordPolicy = password.Policy{
+	MinLength:  8,
+	MaxLength:  128,
+	MinClasses: 2,
+}
+
+// The `PasswordPolicyError` type is the error returned for a new password that breaks the password
+// policy. It holds one error for every broken rule.
+type PasswordPolicyError struct {
+	Errors []types.APIError
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return fmt.Sprintf("pass: the password breaks %d rules of the password policy", len(e.Errors))
+}
+
+// The `bcryptMaxLength` constant is the length in bytes of the longest password bcrypt can hash.
+const bcryptMaxLength = 72
+
+func init() {
+	policy, err := loadPasswordPolicy(PasswordPolicy)
+	if err != nil {
+		panic(err)
+	}
+	PasswordPolicy = policy
+}
+
+// The function `CheckPasswordPolicy` checks a new password of the user with the given username and
+// email address against the password policy. It returns one error for every rule the password breaks,
+// on the given field.
+func CheckPasswordPolicy(field string, pw string, username string, email string) ([]types.APIError, error) {
+	return PasswordPolicy.Check(field, pw, username, email)
+}
+
+// The function `loadPasswordPolicy` overrides the rules of the given policy with the ones configured in
+// the environment and validates the result.
+func loadPasswordPolicy(policy password.Policy) (password.Policy, error) {
+	err := intsFromEnv(map[string]*int{
+		"PASSWORD_MIN_LENGTH":  &policy.MinLength,
+		"PASSWORD_MAX_LENGTH":  &policy.MaxLength,
+		"PASSWORD_MIN_CLASSES": &policy.MinClasses,
+	})
+	if err != nil {
+		return policy, err
+	}
+	// bcrypt only uses the first 72 bytes of a password, so longer passwords are not accepted. A
+	// character can take more than one byte, so the limit is enforced in bytes as well.
+	if _, ok := PasswordHasher.(password.Bcrypt); ok {
+		policy.MaxLength = min(policy.MaxLength, bcryptMaxLength)
+		policy.MaxBytes = bcryptMaxLength
+	}
+
+	if dir := os.Getenv("PASSWORD_BREACHED_DIR"); dir != "" {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return policy, fmt.Errorf("PASSWORD_BREACHED_DIR: %q is not a directory", dir)
+		}
+		policy.Breached = &password.BreachedList{Dir: dir}
+	}
+
+	if policy.MinLength < 1 {
+		return policy, fmt.Errorf("the minimum password length must be at least 1")
+	}
+	if policy.MaxLength < policy.MinLength {
+		return policy, fmt.Errorf("the maximum password length must not be shorter than the minimum length")
+	}
+	if policy.MinClasses < 0 || policy.MinClasses > 4 {
+		return policy, fmt.Errorf("the number of required character classes must be between 0 and 4")
+	}
+	return policy, nil
+}
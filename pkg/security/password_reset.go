@@ -0,0 +1,119 @@
+package security
+
+import (
+	"errors"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/cache"
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+)
+
+// The `PasswordResetLifetime` constant is the time a password reset link is valid for.
+const PasswordResetLifetime = 30 * time.Minute
+
+// The error returned by `ResetPassword` for a token that is unknown, has expired or has been used.
+var InvalidPasswordResetError = errors.New("pass: invalid or expired password reset token")
+
+// The error returned by `RotateRefreshToken` for a refresh token that has been issued before all the
+// tokens of its user were revoked.
+var RevokedTokenError = errors.New("jwt: the token has been revoked")
+
+// The function `IssuePasswordResetToken` issues a random, one-time password reset token for the user.
+// Only the digest of the token is stored, and a new token replaces the previous one.
+func IssuePasswordResetToken(user *models.User) (string, error) {
+	token, err := utils.RandomID(32)
+	if err != nil {
+		return "", err
+	}
+	if err := cache.StorePasswordReset(token, user.UUID, PasswordResetLifetime); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// The function `ResetPassword` sets the new password of the user a reset token was issued for and
+// revokes all the tokens of the user, so that whoever knew the old password is logged out. As the token
+// was sent to the email address of the user, the address is verified and a lockout of the account is
+// lifted as well.
+func ResetPassword(token string, password string) (*models.User, error) {
+	subject, err := cache.GetPasswordReset(token)
+	if err != nil {
+		return nil, InvalidPasswordResetError
+	}
+	user, err := GetSubjectUser(subject)
+	if err != nil {
+		return nil, InvalidPasswordResetError
+	}
+
+	// A password that does not meet the policy can be corrected with the same link.
+	errs, err := CheckPasswordPolicy("password", password, user.Username, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, &PasswordPolicyError{Errors: errs}
+	}
+
+	// The token is used up before the password history is checked, so that the link can not be used to
+	// find out which passwords the user has used before. A reused password needs a new link.
+	if consumed, err := cache.ConsumePasswordReset(token); err != nil || consumed != subject {
+		return nil, InvalidPasswordResetError
+	}
+	reused, err := IsPasswordReused(user, password)
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, PasswordReusedError
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	if err := ChangePassword(user, hash); err != nil {
+		return nil, err
+	}
+	if !user.EmailVerified {
+		if err := user.SetEmailVerified(true); err != nil {
+			return nil, err
+		}
+	}
+
+	// Whoever reset the password has proven to own the account, so a lockout is lifted as well.
+	if err := UnlockAccount(user); err != nil {
+		return nil, err
+	}
+	return user, RevokeUserTokens(user)
+}
+
+// The function `RevokeUserTokens` revokes all the access and refresh tokens of the user by ending all
+// of the sessions of the user. The tokens that do not belong to a session are revoked by the time they
+// have been issued before (see `IsTokenRevokedForUser`).
+func RevokeUserTokens(user *models.User) error {
+	if err := cache.RevokeTokensIssuedBefore(user.UUID, time.Now(), TokenLifetimes.RefreshToken); err != nil {
+		return err
+	}
+
+	var session models.Session
+	sessions, err := session.DeleteUserSessions(user.ID)
+	if err != nil {
+		return err
+	}
+	for i := range sessions {
+		RevokeSession(&sessions[i])
+	}
+	return nil
+}
+
+// The function `IsTokenRevokedForUser` checks if a token has been issued before all the tokens of its
+// user were revoked. Tokens without an issue time are considered to be issued before. Both times are
+// whole seconds, so a token issued in the same second as the revocation is revoked as well.
+func IsTokenRevokedForUser(claims *Claims) bool {
+	notBefore := cache.TokensNotBefore(claims.Subject)
+	if notBefore.IsZero() {
+		return false
+	}
+	return claims.IssuedAt == nil || !claims.IssuedAt.Time.After(notBefore)
+}
@@ -0,0 +1,42 @@
+package security
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/pkg/password"
+)
+
+// The `hashSlots` variable holds the slots that bound the number of passwords that are hashed or
+// verified at the same time. The number of slots is the `PASSWORD_HASH_CONCURRENCY` environment
+// variable and defaults to the number of CPUs; the time a request waits for a free slot is the
+// `PASSWORD_HASH_WAIT` environment variable (e.g. "250ms") and defaults to 100ms.
+var hashSlots *password.Slots
+
+// The error returned when all the slots are busy. It is answered with 503 Service Unavailable.
+var HashingBusyError = password.BusyError
+
+// The `HashingRetryAfter` constant is the time clients are asked to wait when all the slots are busy.
+const HashingRetryAfter = time.Second
+
+func init() {
+	concurrency, wait := runtime.NumCPU(), 100*time.Millisecond
+	if value := os.Getenv("PASSWORD_HASH_CONCURRENCY"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			panic(fmt.Errorf("PASSWORD_HASH_CONCURRENCY: must be a positive number, got %q", value))
+		}
+		concurrency = parsed
+	}
+	if value := os.Getenv("PASSWORD_HASH_WAIT"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			panic(fmt.Errorf("PASSWORD_HASH_WAIT: must be a non-negative duration, got %q", value))
+		}
+		wait = parsed
+	}
+	hashSlots = password.NewSlots(concurrency, wait)
+}
@@ -0,0 +1,107 @@
+package security
+
+import (
+	"crypto/rand"
+	"strings"
+
+	"coderero.dev/projects/go/gin/hello/models"
+)
+
+// The number of recovery codes a user gets and the length of a code. The codes are shown split in two
+// groups (e.g. "k3p7x-9wq2m") and are case insensitive.
+const (
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+// The `recoveryCodeAlphabet` leaves out the characters that are easily confused (0/o, 1/l/i).
+const recoveryCodeAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+// The function `GenerateRecoveryCodes` generates a new set of recovery codes for the user, replacing
+// the codes the user had. The codes are only returned here; they are stored hashed with the password
+// hashing helpers.
+func GenerateRecoveryCodes(user *models.User) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := randomRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := HashPassword(code)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hashes[i] = hash
+	}
+
+	var recoveryCode models.RecoveryCode
+	if err := recoveryCode.ReplaceRecoveryCodes(user.ID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// The function `UseRecoveryCode` checks a recovery code of the user and burns it if it matches, so that
+// every code can only be used once. It returns `HashingBusyError` if the codes can not be compared.
+func UseRecoveryCode(user *models.User, code string) (bool, error) {
+	code = normalizeRecoveryCode(code)
+	if len(code) != recoveryCodeLength {
+		return false, nil
+	}
+
+	var recoveryCode models.RecoveryCode
+	for _, stored := range recoveryCode.GetRecoveryCodes(user.ID) {
+		match, err := ComparePassword(code, stored.Code)
+		if err != nil {
+			return false, err
+		}
+		if match {
+			return stored.Use(), nil
+		}
+	}
+	return false, nil
+}
+
+// The function `RecoveryCodesLeft` returns the number of recovery codes the user has not used yet.
+func RecoveryCodesLeft(user *models.User) int64 {
+	var recoveryCode models.RecoveryCode
+	return recoveryCode.CountRecoveryCodes(user.ID)
+}
+
+// The function `DeleteRecoveryCodes` deletes all the recovery codes of the user.
+func DeleteRecoveryCodes(user *models.User) error {
+	var recoveryCode models.RecoveryCode
+	return recoveryCode.ReplaceRecoveryCodes(user.ID, nil)
+}
+
+// The function `randomRecoveryCode` generates a random recovery code from the recovery code alphabet.
+func randomRecoveryCode() (string, error) {
+	random := make([]byte, recoveryCodeLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	// 31 characters do not divide 256, so bytes that would make some characters more likely are drawn
+	// again.
+	limit := byte(256 - 256%len(recoveryCodeAlphabet))
+	code := make([]byte, 0, recoveryCodeLength)
+	for len(code) < recoveryCodeLength {
+		for _, b := range random {
+			if b < limit && len(code) < recoveryCodeLength {
+				code = append(code, recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+			}
+		}
+		if _, err := rand.Read(random); err != nil {
+			return "", err
+		}
+	}
+	return string(code), nil
+}
+
+// The function `normalizeRecoveryCode` removes the separators and spaces of a recovery code as typed by
+// the user and lower cases it.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,50 @@
+package security
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+)
+
+// The `emailSubjectsUntil` variable is the end of the compatibility window in which tokens whose
+// subject is the email of the user, as issued before the UUID of the user became the subject, are still
+// accepted. It is read from the `EMAIL_SUBJECTS_UNTIL` environment variable (RFC 3339). If it is not set
+// these tokens are accepted until they expire.
+var emailSubjectsUntil time.Time
+
+// The error returned by `GetSubjectUser` for a subject that is neither a UUID nor an accepted email.
+var InvalidSubjectError = errors.New("jwt: invalid token subject")
+
+func init() {
+	if value := os.Getenv("EMAIL_SUBJECTS_UNTIL"); value != "" {
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			panic(err)
+		}
+		emailSubjectsUntil = until
+	}
+}
+
+// The function `GetSubjectUser` returns the user the subject of a token identifies. The subject is the
+// UUID of the user, or the email of the user for tokens issued before the compatibility window closed.
+func GetSubjectUser(sub string) (*models.User, error) {
+	var user models.User
+	if utils.IsUUID(sub) {
+		if err := user.GetUserByUUID(sub); err != nil {
+			return nil, err
+		}
+		return &user, nil
+	}
+
+	if !strings.Contains(sub, "@") || (!emailSubjectsUntil.IsZero() && time.Now().After(emailSubjectsUntil)) {
+		return nil, InvalidSubjectError
+	}
+	if err := user.GetUserByEmail(sub); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,79 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/cache"
+	"coderero.dev/projects/go/gin/hello/pkg/totp"
+)
+
+// The TOTP parameters besides the ones of the `totp` package. Codes of one time step before and after
+// the current one are accepted to tolerate clock drift.
+const (
+	totpSkew      = 1
+	totpSecretLen = 20
+)
+
+// The `totpIssuer` variable is the name the authenticator apps show for the accounts of this service.
+// It is read from the `TOTP_ISSUER` environment variable.
+var totpIssuer = "disto"
+
+// The `totpEncoding` variable is the base32 encoding the TOTP secrets are shared with.
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func init() {
+	if value := os.Getenv("TOTP_ISSUER"); value != "" {
+		totpIssuer = value
+	}
+}
+
+// The function `GenerateTOTPSecret` generates a random base32 encoded TOTP secret.
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// The function `TOTPURI` returns the `otpauth://` URI of a TOTP secret, which authenticator apps read
+// from a QR code.
+func TOTPURI(secret string, account string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", totpIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totp.Digits))
+	query.Set("period", fmt.Sprint(totp.Period))
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + totpIssuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+	return uri.String()
+}
+
+// The function `ValidateTOTP` checks a TOTP code of the subject against the secret. A code is only
+// accepted once, so that an observed code can not be replayed.
+func ValidateTOTP(subject string, secret string, code string) bool {
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(code) != totp.Digits {
+		return false
+	}
+
+	step := time.Now().Unix() / totp.Period
+	for offset := int64(-totpSkew); offset <= totpSkew; offset++ {
+		if subtle.ConstantTimeCompare([]byte(totp.Code(key, step+offset)), []byte(code)) == 1 {
+			return cache.UseTOTPStep(subject, step+offset, (2*totpSkew+1)*totp.Period*time.Second)
+		}
+	}
+	return false
+}
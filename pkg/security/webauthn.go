@@ -0,0 +1,209 @@
+package security
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/cache"
+	"coderero.dev/projects/go/gin/hello/models"
+	"coderero.dev/projects/go/gin/hello/pkg/webauthn"
+)
+
+// The `webAuthnTimeout` constant is the time the user has to complete a WebAuthn ceremony.
+const webAuthnTimeout = 5 * time.Minute
+
+// The types of the WebAuthn ceremonies, as named in the client data.
+const (
+	webAuthnRegistration = "webauthn.create"
+	webAuthnLogin        = "webauthn.get"
+)
+
+// The errors returned when a WebAuthn ceremony can not be completed.
+var (
+	InvalidWebAuthnCeremonyError   = errors.New("webauthn: unknown or expired ceremony")
+	WebAuthnCredentialExistsError  = errors.New("webauthn: the credential is already registered")
+	UnknownWebAuthnCredentialError = errors.New("webauthn: unknown credential")
+)
+
+// The `relyingParty` variable describes this service to the authenticators. It is read from the
+// `WEBAUTHN_RP_ID`, `WEBAUTHN_RP_NAME` and `WEBAUTHN_ORIGINS` (comma separated) environment variables;
+// the origins default to the `ALLOWED_ORIGINS` of the CORS configuration.
+var relyingParty webauthn.RelyingParty
+
+// The `webAuthnCeremony` struct holds a pending WebAuthn ceremony. The subject is the user that
+// registers a credential; it is empty for a login, where the credential identifies the user.
+type webAuthnCeremony struct {
+	Type    string `json:"type"`
+	Subject string `json:"sub,omitempty"`
+}
+
+func init() {
+	relyingParty = webauthn.RelyingParty{
+		ID:   os.Getenv("WEBAUTHN_RP_ID"),
+		Name: os.Getenv("WEBAUTHN_RP_NAME"),
+	}
+	if relyingParty.ID == "" {
+		relyingParty.ID = "localhost"
+	}
+	if relyingParty.Name == "" {
+		relyingParty.Name = totpIssuer
+	}
+
+	origins := os.Getenv("WEBAUTHN_ORIGINS")
+	if origins == "" {
+		origins = os.Getenv("ALLOWED_ORIGINS")
+	}
+	for _, origin := range strings.Split(origins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			relyingParty.Origins = append(relyingParty.Origins, origin)
+		}
+	}
+}
+
+// The function `BeginWebAuthnRegistration` starts the registration of a new credential for the user
+// and returns the options for `navigator.credentials.create()`. The credentials the user has already
+// registered are excluded.
+func BeginWebAuthnRegistration(user *models.User) (*webauthn.CreationOptions, error) {
+	challenge, err := beginWebAuthnCeremony(webAuthnCeremony{Type: webAuthnRegistration, Subject: user.UUID})
+	if err != nil {
+		return nil, err
+	}
+
+	var credential models.WebAuthnCredential
+	var exclude [][]byte
+	for _, registered := range credential.GetUserCredentials(user.ID) {
+		if id, err := base64.RawURLEncoding.DecodeString(registered.CredentialID); err == nil {
+			exclude = append(exclude, id)
+		}
+	}
+
+	options := relyingParty.CreationOptions(challenge, webauthn.UserEntity{
+		ID:          []byte(user.UUID),
+		Name:        user.Username,
+		DisplayName: strings.TrimSpace(user.FirstName + " " + user.LastName),
+	}, exclude, webAuthnTimeout)
+	return &options, nil
+}
+
+// The function `FinishWebAuthnRegistration` verifies the response of the authenticator to a
+// registration started for the user and stores the new credential under the given name.
+func FinishWebAuthnRegistration(user *models.User, response webauthn.AttestationResponse, name string) (*models.WebAuthnCredential, error) {
+	challenge, ceremony, err := finishWebAuthnCeremony(response.Response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	if ceremony.Type != webAuthnRegistration || ceremony.Subject != user.UUID {
+		return nil, InvalidWebAuthnCeremonyError
+	}
+
+	verified, err := relyingParty.VerifyRegistration(challenge, response, true)
+	if err != nil {
+		return nil, err
+	}
+
+	credential := &models.WebAuthnCredential{
+		UserID:       user.ID,
+		CredentialID: base64.RawURLEncoding.EncodeToString(verified.ID),
+		PublicKey:    verified.PublicKey,
+		SignCount:    verified.SignCount,
+		Name:         name,
+	}
+	var existing models.WebAuthnCredential
+	if existing.GetCredential(credential.CredentialID) == nil {
+		return nil, WebAuthnCredentialExistsError
+	}
+	if err := credential.Create(); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// The function `BeginWebAuthnLogin` starts a passwordless login and returns the options for
+// `navigator.credentials.get()`. No credentials are listed, so the authenticator offers the passkeys it
+// holds for this service and the user does not have to be known beforehand.
+func BeginWebAuthnLogin() (*webauthn.RequestOptions, error) {
+	challenge, err := beginWebAuthnCeremony(webAuthnCeremony{Type: webAuthnLogin})
+	if err != nil {
+		return nil, err
+	}
+	options := relyingParty.RequestOptions(challenge, nil, webAuthnTimeout)
+	return &options, nil
+}
+
+// The function `FinishWebAuthnLogin` verifies the response of the authenticator to a passwordless login
+// and returns the user the credential belongs to. The authenticator has to have verified the user, so
+// that the credential is a second factor in itself.
+func FinishWebAuthnLogin(response webauthn.AssertionResponse) (*models.User, error) {
+	challenge, ceremony, err := finishWebAuthnCeremony(response.Response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	if ceremony.Type != webAuthnLogin {
+		return nil, InvalidWebAuthnCeremonyError
+	}
+
+	var credential models.WebAuthnCredential
+	if err := credential.GetCredential(base64.RawURLEncoding.EncodeToString(response.RawID)); err != nil {
+		return nil, UnknownWebAuthnCredentialError
+	}
+	var user models.User
+	if user.GetUserById(int(credential.UserID)).ID == 0 {
+		return nil, UnknownWebAuthnCredentialError
+	}
+	if len(response.Response.UserHandle) != 0 && string(response.Response.UserHandle) != user.UUID {
+		return nil, webauthn.CredentialMismatchError
+	}
+
+	signCount, err := relyingParty.VerifyAssertion(challenge, webauthn.Credential{
+		ID:        response.RawID,
+		PublicKey: credential.PublicKey,
+		SignCount: credential.SignCount,
+	}, response, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := credential.Used(signCount); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// The function `beginWebAuthnCeremony` generates the challenge of a new ceremony and stores the
+// ceremony under it until it expires.
+func beginWebAuthnCeremony(ceremony webAuthnCeremony) ([]byte, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+	value, err := json.Marshal(ceremony)
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.StoreWebAuthnChallenge(base64.RawURLEncoding.EncodeToString(challenge), value, webAuthnTimeout); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// The function `finishWebAuthnCeremony` returns the challenge the response was created for and the
+// ceremony stored under it. The ceremony is deleted, so that a challenge can only be answered once.
+func finishWebAuthnCeremony(clientDataJSON []byte) ([]byte, *webAuthnCeremony, error) {
+	challenge, err := webauthn.Challenge(clientDataJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	value, err := cache.ConsumeWebAuthnChallenge(base64.RawURLEncoding.EncodeToString(challenge))
+	if err != nil {
+		return nil, nil, InvalidWebAuthnCeremonyError
+	}
+
+	var ceremony webAuthnCeremony
+	if err := json.Unmarshal(value, &ceremony); err != nil {
+		return nil, nil, InvalidWebAuthnCeremonyError
+	}
+	return challenge, &ceremony, nil
+}
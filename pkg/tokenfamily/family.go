@@ -0,0 +1,72 @@
+package tokenfamily
+
+import (
+	"errors"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/pkg/utils"
+)
+
+// The errors returned by `Rotate` when the presented refresh token can not be exchanged.
+var (
+	NotFoundError = errors.New("token family: refresh token family does not exist or has been revoked")
+	ReusedError   = errors.New("token family: refresh token has already been used")
+)
+
+// The `Store` interface is the storage of the refresh token families. Every family holds the digest of
+// the only refresh token of the family that may still be exchanged. `CompareAndSwap` replaces the
+// digest of a family atomically, and reports if the family exists and if the digest was the given one.
+type Store interface {
+	Set(family string, digest string, ttl time.Duration) error
+	Get(family string) (string, bool, error)
+	CompareAndSwap(family string, old string, new string, ttl time.Duration) (bool, bool, error)
+	Delete(family string) error
+}
+
+// The `legacyAccessTokenLifetime` constant is the lifetime of the access tokens issued before token
+// families existed.
+const legacyAccessTokenLifetime = 5 * time.Minute
+
+// The function `IsRefreshToken` reports if a token with the given family, ID, session and expiry may be
+// exchanged for new tokens. Refresh tokens name the family they belong to. Refresh tokens issued before
+// token families existed carry neither a token ID nor a session, which every token issued since carries,
+// so that a current access token is never taken for one of them. The access tokens of that time did not
+// carry them either, but they never lived longer than five minutes.
+func IsRefreshToken(family string, id string, session string, expiresAt time.Time, now time.Time) bool {
+	if family != "" {
+		return true
+	}
+	return id == "" && session == "" && expiresAt.Sub(now) > legacyAccessTokenLifetime
+}
+
+// The function `Start` creates a new refresh token family whose current token is `token`. The family
+// expires together with the token.
+func Start(store Store, family string, token string, ttl time.Duration) error {
+	return store.Set(family, utils.TokenDigest(token), ttl)
+}
+
+// The function `Rotate` replaces the current token of a family with `next`. If `used` is not the current
+// token of the family, the token has been used before and the whole family is revoked, so that neither
+// the attacker nor the legitimate client can keep using it, and `ReusedError` is returned.
+func Rotate(store Store, family string, used string, next string, ttl time.Duration) error {
+	found, swapped, err := store.CompareAndSwap(family, utils.TokenDigest(used), utils.TokenDigest(next), ttl)
+	switch {
+	case err != nil:
+		return err
+	case !found:
+		return NotFoundError
+	case !swapped:
+		if err := store.Delete(family); err != nil {
+			return err
+		}
+		return ReusedError
+	}
+	return nil
+}
+
+// The function `IsCurrent` checks if `token` is the current token of a family that has not been
+// revoked.
+func IsCurrent(store Store, family string, token string) bool {
+	current, found, err := store.Get(family)
+	return err == nil && found && current == utils.TokenDigest(token)
+}
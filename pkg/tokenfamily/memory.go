@@ -0,0 +1,69 @@
+package tokenfamily
+
+import (
+	"sync"
+	"time"
+)
+
+// The `MemoryStore` struct is a `Store` that keeps the families in the memory of the process. It is only
+// suited for a single process, e.g. in tests.
+type MemoryStore struct {
+	mu       sync.Mutex
+	families map[string]memoryFamily
+}
+
+// The `memoryFamily` struct holds the current digest of a family and the time the family expires.
+type memoryFamily struct {
+	digest  string
+	expires time.Time
+}
+
+// The function `NewMemoryStore` returns an empty `MemoryStore`.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{families: make(map[string]memoryFamily)}
+}
+
+func (m *MemoryStore) Set(family string, digest string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.families[family] = memoryFamily{digest: digest, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (m *MemoryStore) Get(family string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, ok := m.current(family)
+	return current.digest, ok, nil
+}
+
+func (m *MemoryStore) CompareAndSwap(family string, old string, new string, ttl time.Duration) (bool, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, ok := m.current(family)
+	if !ok {
+		return false, false, nil
+	}
+	if current.digest != old {
+		return true, false, nil
+	}
+	m.families[family] = memoryFamily{digest: new, expires: time.Now().Add(ttl)}
+	return true, true, nil
+}
+
+func (m *MemoryStore) Delete(family string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.families, family)
+	return nil
+}
+
+// The `current` method returns the family if it exists and has not expired. The lock must be held.
+func (m *MemoryStore) current(family string) (memoryFamily, bool) {
+	f, ok := m.families[family]
+	if !ok || time.Now().After(f.expires) {
+		delete(m.families, family)
+		return memoryFamily{}, false
+	}
+	return f, true
+}
@@ -0,0 +1,30 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+)
+
+// The TOTP parameters (RFC 6238). These are the defaults every authenticator app supports, so they are
+// not configurable.
+const (
+	Period = 30
+	Digits = 6
+)
+
+// The function `Code` computes the TOTP code of a time step, which is the Unix time divided by the
+// period (RFC 4226, section 5.3).
+func Code(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%1000000)
+}
@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -21,6 +25,50 @@ func ContainsFile(files []fs.DirEntry, name string) bool {
 	return false
 }
 
+// The function `RandomID` returns a URL-safe random identifier generated from `n` random bytes.
+func RandomID(n int) (string, error) {
+	b, err := randomBytes(n)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// The function `TokenDigest` returns the SHA-256 digest of a token, so that the raw tokens are never
+// stored.
+func TokenDigest(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// The function `randomBytes` returns `n` random bytes.
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// The `uuidPattern` matches the canonical textual representation of a UUID.
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// The function `NewUUID` returns a random (version 4) UUID in its canonical textual representation.
+func NewUUID() (string, error) {
+	b, err := randomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// The function `IsUUID` checks if the given string is a UUID in its canonical textual representation.
+func IsUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
+
 // The function "ExtractInformation" extracts information from a given error message and returns a
 // formatted string describing the error.
 func ExtractInformation(err error) string {
@@ -0,0 +1,110 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// The COSE key types, key parameters and curves (RFC 9053) of the supported public keys.
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurve   = -1
+	coseX       = -2
+	coseY       = -3
+	coseModulus = -1
+	coseExp     = -2
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+
+	minRSAKeyBits = 2048
+)
+
+// The function `parsePublicKey` parses a COSE encoded public key. Only the keys of the algorithms
+// offered in the creation options are accepted.
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	var key map[int]cbor.RawMessage
+	if err := cbor.Unmarshal(data, &key); err != nil {
+		return nil, UnsupportedKeyError
+	}
+
+	var kty, alg int
+	if cbor.Unmarshal(key[coseKeyType], &kty) != nil || cbor.Unmarshal(key[coseAlgorithm], &alg) != nil {
+		return nil, UnsupportedKeyError
+	}
+
+	switch {
+	case kty == coseKeyTypeEC2 && alg == ES256:
+		var crv int
+		var x, y []byte
+		if cbor.Unmarshal(key[coseCurve], &crv) != nil || crv != coseCurveP256 ||
+			cbor.Unmarshal(key[coseX], &x) != nil || cbor.Unmarshal(key[coseY], &y) != nil ||
+			len(x) != 32 || len(y) != 32 {
+			return nil, UnsupportedKeyError
+		}
+		// The point is checked to be on the curve before it is used.
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, UnsupportedKeyError
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+
+	case kty == coseKeyTypeOKP && alg == EdDSA:
+		var crv int
+		var x []byte
+		if cbor.Unmarshal(key[coseCurve], &crv) != nil || crv != coseCurveEd25519 ||
+			cbor.Unmarshal(key[coseX], &x) != nil || len(x) != ed25519.PublicKeySize {
+			return nil, UnsupportedKeyError
+		}
+		return ed25519.PublicKey(x), nil
+
+	case kty == coseKeyTypeRSA && alg == RS256:
+		var n, e []byte
+		if cbor.Unmarshal(key[coseModulus], &n) != nil || cbor.Unmarshal(key[coseExp], &e) != nil || len(e) == 0 || len(e) > 4 {
+			return nil, UnsupportedKeyError
+		}
+		modulus := new(big.Int).SetBytes(n)
+		if modulus.BitLen() < minRSAKeyBits {
+			return nil, UnsupportedKeyError
+		}
+		return &rsa.PublicKey{N: modulus, E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	}
+	return nil, UnsupportedKeyError
+}
+
+// The function `verifySignature` verifies a signature over the data with a COSE encoded public key.
+func verifySignature(publicKey []byte, data []byte, signature []byte) error {
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(data)
+	valid := false
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, data, signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+	if !valid {
+		return InvalidSignatureError
+	}
+	return nil
+}
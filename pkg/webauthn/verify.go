@@ -0,0 +1,201 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// The flags of the authenticator data.
+const (
+	flagUserPresent             = 0x01
+	flagUserVerified            = 0x04
+	flagAttestedCredentialData  = 0x40
+	maxCredentialIDLength       = 1023
+	authenticatorDataHeaderSize = 37
+)
+
+// The `clientData` struct holds the members of the client data the browser signs over.
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// The `attestationObject` struct holds the members of the attestation object of a new credential.
+type attestationObject struct {
+	Format    string          `cbor:"fmt"`
+	Statement cbor.RawMessage `cbor:"attStmt"`
+	AuthData  []byte          `cbor:"authData"`
+}
+
+// The `authenticatorData` struct holds the parsed authenticator data. The credential ID and public key
+// are only present when a credential is registered.
+type authenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// The function `Challenge` returns the challenge of the client data of a response, without verifying
+// anything. It is used to look up the ceremony the response belongs to.
+func Challenge(clientDataJSON []byte) ([]byte, error) {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil {
+		return nil, InvalidClientDataError
+	}
+	challenge, err := base64.RawURLEncoding.DecodeString(data.Challenge)
+	if err != nil || len(challenge) == 0 {
+		return nil, InvalidClientDataError
+	}
+	return challenge, nil
+}
+
+// The `VerifyRegistration` method verifies the response to the creation options issued with the given
+// challenge (WebAuthn Level 2, section 7.1) and returns the new credential. As attestation is not
+// requested, the attestation statement is not verified. If `requireUserVerification` is set, the
+// authenticator has to have verified the user, e.g. with a PIN or biometrics.
+func (rp RelyingParty) VerifyRegistration(challenge []byte, response AttestationResponse, requireUserVerification bool) (*Credential, error) {
+	if err := rp.verifyClientData(response.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	var attestation attestationObject
+	if err := cbor.Unmarshal(response.Response.AttestationObject, &attestation); err != nil {
+		return nil, InvalidAttestationError
+	}
+	auth, err := parseAuthenticatorData(attestation.AuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(auth, requireUserVerification); err != nil {
+		return nil, err
+	}
+
+	if auth.Flags&flagAttestedCredentialData == 0 {
+		return nil, InvalidAuthenticatorDataError
+	}
+	if !bytes.Equal(auth.CredentialID, response.RawID) {
+		return nil, CredentialMismatchError
+	}
+	if _, err := parsePublicKey(auth.PublicKey); err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:        auth.CredentialID,
+		PublicKey: auth.PublicKey,
+		SignCount: auth.SignCount,
+	}, nil
+}
+
+// The `VerifyAssertion` method verifies the response to the request options issued with the given
+// challenge (WebAuthn Level 2, section 7.2) with the stored credential and returns the new sign count of
+// the credential. A sign count that does not increase means that the credential has been cloned.
+func (rp RelyingParty) VerifyAssertion(challenge []byte, credential Credential, response AssertionResponse, requireUserVerification bool) (uint32, error) {
+	if !bytes.Equal(credential.ID, response.RawID) {
+		return 0, CredentialMismatchError
+	}
+	if err := rp.verifyClientData(response.Response.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+
+	auth, err := parseAuthenticatorData(response.Response.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+	if err := rp.verifyAuthenticatorData(auth, requireUserVerification); err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(response.Response.ClientDataJSON)
+	signed := append(append([]byte{}, response.Response.AuthenticatorData...), clientDataHash[:]...)
+	if err := verifySignature(credential.PublicKey, signed, response.Response.Signature); err != nil {
+		return 0, err
+	}
+
+	if (auth.SignCount != 0 || credential.SignCount != 0) && auth.SignCount <= credential.SignCount {
+		return 0, SignCountError
+	}
+	return auth.SignCount, nil
+}
+
+// The `verifyClientData` method checks the type, the challenge and the origin of the client data.
+func (rp RelyingParty) verifyClientData(clientDataJSON []byte, ceremony string, challenge []byte) error {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil || data.Type != ceremony {
+		return InvalidClientDataError
+	}
+
+	received, err := base64.RawURLEncoding.DecodeString(data.Challenge)
+	if err != nil || subtle.ConstantTimeCompare(received, challenge) != 1 {
+		return ChallengeMismatchError
+	}
+
+	for _, origin := range rp.Origins {
+		if data.Origin == origin {
+			return nil
+		}
+	}
+	return OriginMismatchError
+}
+
+// The `verifyAuthenticatorData` method checks that the authenticator data is scoped to the relying
+// party and that the user was present and, if required, verified.
+func (rp RelyingParty) verifyAuthenticatorData(auth *authenticatorData, requireUserVerification bool) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if subtle.ConstantTimeCompare(auth.RPIDHash, rpIDHash[:]) != 1 {
+		return RPIDMismatchError
+	}
+	if auth.Flags&flagUserPresent == 0 {
+		return UserNotPresentError
+	}
+	if requireUserVerification && auth.Flags&flagUserVerified == 0 {
+		return UserNotVerifiedError
+	}
+	return nil
+}
+
+// The function `parseAuthenticatorData` parses the authenticator data (WebAuthn Level 2, section 6.1).
+// Extensions that follow the attested credential data are ignored.
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < authenticatorDataHeaderSize {
+		return nil, InvalidAuthenticatorDataError
+	}
+
+	auth := &authenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if auth.Flags&flagAttestedCredentialData == 0 {
+		return auth, nil
+	}
+
+	// The attested credential data is the AAGUID of the authenticator (16 bytes), the length of the
+	// credential ID (2 bytes), the credential ID and the CBOR encoded public key.
+	rest := data[authenticatorDataHeaderSize:]
+	if len(rest) < 18 {
+		return nil, InvalidAuthenticatorDataError
+	}
+	length := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if length == 0 || length > maxCredentialIDLength || len(rest) < length {
+		return nil, InvalidAuthenticatorDataError
+	}
+	auth.CredentialID = rest[:length]
+
+	var publicKey cbor.RawMessage
+	if _, err := cbor.UnmarshalFirst(rest[length:], &publicKey); err != nil {
+		return nil, InvalidAuthenticatorDataError
+	}
+	auth.PublicKey = publicKey
+	return auth, nil
+}
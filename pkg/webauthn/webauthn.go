@@ -0,0 +1,209 @@
+package webauthn
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// The COSE algorithms (RFC 9053) of the credentials that are accepted, in order of preference.
+const (
+	ES256 = -7
+	EdDSA = -8
+	RS256 = -257
+)
+
+// The `challengeLength` constant is the length of the random challenges in bytes. The specification
+// requires at least 16 bytes.
+const challengeLength = 32
+
+// The errors returned when a ceremony can not be verified.
+var (
+	InvalidClientDataError        = errors.New("webauthn: invalid client data")
+	ChallengeMismatchError        = errors.New("webauthn: the challenge does not match")
+	OriginMismatchError           = errors.New("webauthn: the origin is not allowed")
+	InvalidAttestationError       = errors.New("webauthn: invalid attestation object")
+	InvalidAuthenticatorDataError = errors.New("webauthn: invalid authenticator data")
+	RPIDMismatchError             = errors.New("webauthn: the credential is scoped to another relying party")
+	UserNotPresentError           = errors.New("webauthn: the user was not present")
+	UserNotVerifiedError          = errors.New("webauthn: the user was not verified")
+	CredentialMismatchError       = errors.New("webauthn: the credential does not match")
+	UnsupportedKeyError           = errors.New("webauthn: unsupported public key")
+	InvalidSignatureError         = errors.New("webauthn: invalid signature")
+	SignCountError                = errors.New("webauthn: the sign count did not increase, the authenticator may be cloned")
+)
+
+// The `Base64URL` type holds binary data that is exchanged with the browser as unpadded base64url, as
+// done by the JSON serialization of WebAuthn. Padded input is accepted as well.
+type Base64URL []byte
+
+// The `MarshalJSON` method encodes the data as an unpadded base64url string.
+func (b Base64URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+// The `UnmarshalJSON` method decodes a base64url string.
+func (b *Base64URL) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// The `RelyingParty` struct describes the service credentials are registered with. The `ID` is the
+// domain the credentials are scoped to and `Origins` are the origins the ceremonies may be run on.
+type RelyingParty struct {
+	ID      string
+	Name    string
+	Origins []string
+}
+
+// The `Credential` struct holds what is stored about a registered credential: its ID, its COSE encoded
+// public key and the last sign count reported by the authenticator.
+type Credential struct {
+	ID        []byte
+	PublicKey []byte
+	SignCount uint32
+}
+
+// The `RelyingPartyEntity` struct is the `rp` member of the creation options.
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// The `UserEntity` struct is the `user` member of the creation options. The `ID` is the user handle,
+// which must not contain personal information.
+type UserEntity struct {
+	ID          Base64URL `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"displayName"`
+}
+
+// The `CredentialParameter` struct is a member of the `pubKeyCredParams` of the creation options.
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// The `CredentialDescriptor` struct names a credential in the creation and request options.
+type CredentialDescriptor struct {
+	Type string    `json:"type"`
+	ID   Base64URL `json:"id"`
+}
+
+// The `AuthenticatorSelection` struct is the `authenticatorSelection` member of the creation options.
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// The `CreationOptions` struct holds the options of `navigator.credentials.create()`.
+type CreationOptions struct {
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	Challenge              Base64URL              `json:"challenge"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// The `RequestOptions` struct holds the options of `navigator.credentials.get()`.
+type RequestOptions struct {
+	Challenge        Base64URL              `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// The `AttestationResponse` struct is the JSON serialization of the credential returned by
+// `navigator.credentials.create()`.
+type AttestationResponse struct {
+	ID       string    `json:"id"`
+	RawID    Base64URL `json:"rawId"`
+	Type     string    `json:"type"`
+	Response struct {
+		ClientDataJSON    Base64URL `json:"clientDataJSON"`
+		AttestationObject Base64URL `json:"attestationObject"`
+	} `json:"response"`
+}
+
+// The `AssertionResponse` struct is the JSON serialization of the credential returned by
+// `navigator.credentials.get()`.
+type AssertionResponse struct {
+	ID       string    `json:"id"`
+	RawID    Base64URL `json:"rawId"`
+	Type     string    `json:"type"`
+	Response struct {
+		ClientDataJSON    Base64URL `json:"clientDataJSON"`
+		AuthenticatorData Base64URL `json:"authenticatorData"`
+		Signature         Base64URL `json:"signature"`
+		UserHandle        Base64URL `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// The function `NewChallenge` generates a random challenge for a ceremony.
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, challengeLength)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// The `CreationOptions` method returns the options to register a new credential for the user. The
+// credentials in `exclude` are already registered and are not registered again. Attestation is not
+// requested, as the authenticators are not restricted to certain models. User verification is required
+// as it is for signing in, so that no credential is registered that can never be used to sign in.
+func (rp RelyingParty) CreationOptions(challenge []byte, user UserEntity, exclude [][]byte, timeout time.Duration) CreationOptions {
+	return CreationOptions{
+		RP:        RelyingPartyEntity{ID: rp.ID, Name: rp.Name},
+		User:      user,
+		Challenge: challenge,
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Alg: ES256},
+			{Type: "public-key", Alg: EdDSA},
+			{Type: "public-key", Alg: RS256},
+		},
+		Timeout:            timeout.Milliseconds(),
+		ExcludeCredentials: descriptors(exclude),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "required",
+		},
+		Attestation: "none",
+	}
+}
+
+// The `RequestOptions` method returns the options to sign in with a credential. If `allow` is empty
+// the authenticator offers the discoverable credentials of the relying party.
+func (rp RelyingParty) RequestOptions(challenge []byte, allow [][]byte, timeout time.Duration) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          timeout.Milliseconds(),
+		RPID:             rp.ID,
+		AllowCredentials: descriptors(allow),
+		UserVerification: "required",
+	}
+}
+
+// The function `descriptors` returns the credential descriptors of the given credential IDs.
+func descriptors(ids [][]byte) []CredentialDescriptor {
+	var list []CredentialDescriptor
+	for _, id := range ids {
+		list = append(list, CredentialDescriptor{Type: "public-key", ID: id})
+	}
+	return list
+}
@@ -0,0 +1,28 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/pkg/lockout"
+)
+
+func TestLockoutBackoff(t *testing.T) {
+	policy := lockout.Policy{BackoffBase: time.Second, BackoffMax: 30 * time.Second}
+	for failures, want := range map[int64]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		5:  16 * time.Second,
+		6:  30 * time.Second,
+		60: 30 * time.Second,
+	} {
+		if delay := policy.Backoff(failures); delay != want {
+			t.Fatalf("after %d failures: expected %s, got %s", failures, want, delay)
+		}
+	}
+
+	if delay := (lockout.Policy{}).Backoff(3); delay != 0 {
+		t.Fatalf("expected no back-off, got %s", delay)
+	}
+}
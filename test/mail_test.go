@@ -0,0 +1,31 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/pkg/mail"
+)
+
+func TestMemoryTransportKeepsVerificationMail(t *testing.T) {
+	outbox := &mail.MemoryTransport{}
+	previous := mail.SetTransport(outbox)
+	defer mail.SetTransport(previous)
+
+	link := "https://app.example.com/api/v1/auth/verify-email?token=abc"
+	if err := mail.Send(mail.VerificationMessage("jane@example.com", "Jane", link, 24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, ok := outbox.Last("jane@example.com")
+	if !ok {
+		t.Fatal("expected a message for jane@example.com")
+	}
+	if !strings.Contains(msg.Body, link) || !strings.Contains(msg.Body, "24 hours") {
+		t.Fatalf("unexpected body: %q", msg.Body)
+	}
+	if _, ok := outbox.Last("john@example.com"); ok {
+		t.Fatal("expected no message for john@example.com")
+	}
+}
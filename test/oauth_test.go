@@ -0,0 +1,60 @@
+package test
+
+import (
+	"testing"
+
+	"coderero.dev/projects/go/gin/hello/pkg/oauth"
+)
+
+// The code verifier and code challenge of RFC 7636, Appendix B.
+const (
+	pkceVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	pkceChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func TestPKCECodeChallenge(t *testing.T) {
+	if challenge := oauth.CodeChallenge(pkceVerifier); challenge != pkceChallenge {
+		t.Fatalf("expected %q, got %q", pkceChallenge, challenge)
+	}
+	if !oauth.IsValidCodeChallenge(pkceChallenge) {
+		t.Fatal("expected the challenge to be valid")
+	}
+	if !oauth.VerifyCodeVerifier(pkceVerifier, pkceChallenge) {
+		t.Fatal("expected the verifier to match the challenge")
+	}
+}
+
+func TestPKCERejectsWrongVerifiers(t *testing.T) {
+	for _, verifier := range []string{
+		"",
+		"dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXj",
+		"too-short",
+		pkceVerifier + "+",
+	} {
+		if oauth.VerifyCodeVerifier(verifier, pkceChallenge) {
+			t.Fatalf("expected %q to be rejected", verifier)
+		}
+	}
+	if oauth.IsValidCodeChallenge("plain") {
+		t.Fatal("expected a short challenge to be invalid")
+	}
+}
+
+func TestGrantedScope(t *testing.T) {
+	for _, test := range []struct {
+		allowed, requested, granted string
+		ok                          bool
+	}{
+		{"openid profile email", "", "openid profile email", true},
+		{"openid  profile", "  ", "openid profile", true},
+		{"openid profile email", "email openid", "email openid", true},
+		{"openid profile", "openid email", "", false},
+		{"", "openid", "", false},
+		{"", "", "", true},
+	} {
+		granted, ok := oauth.GrantedScope(test.allowed, test.requested)
+		if granted != test.granted || ok != test.ok {
+			t.Fatalf("GrantedScope(%q, %q) = %q, %v, expected %q, %v", test.allowed, test.requested, granted, ok, test.granted, test.ok)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/pkg/password"
+)
+
+// A hash of "hunter22!" in the legacy `$disto$` format.
+const legacyHash = "$disto$14$8$XhJF+pYLE2njYLd1Gk/0VmIt2hnieqI2ey2XlN8a1RN0P/24kHQHw"
+
+var hashers = map[string]password.Algorithm{
+	"scrypt":   password.Scrypt{LogN: 10, R: 8, P: 1, KeyLen: 32},
+	"argon2id": password.Argon2id{Memory: 64, Time: 1, Threads: 1, KeyLen: 32},
+	"bcrypt":   password.Bcrypt{Cost: 4},
+}
+
+func TestPasswordHashRoundTrip(t *testing.T) {
+	for name, hasher := range hashers {
+		hash, err := hasher.Hash("correct horse")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := password.Verify("correct horse", hash); err != nil {
+			t.Fatalf("%s: expected %q to verify: %v", name, hash, err)
+		}
+		if err := password.Verify("wrong horse", hash); !errors.Is(err, password.MismatchError) {
+			t.Fatalf("%s: expected a mismatch, got %v", name, err)
+		}
+		if password.NeedsRehash(hash, hasher) {
+			t.Fatalf("%s: a hash with the current parameters should not need a rehash", name)
+		}
+	}
+}
+
+func TestLegacyPasswordHashVerifies(t *testing.T) {
+	if err := password.Verify("hunter22!", legacyHash); err != nil {
+		t.Fatalf("expected the legacy hash to verify: %v", err)
+	}
+	if err := password.Verify("hunter22?", legacyHash); !errors.Is(err, password.MismatchError) {
+		t.Fatalf("expected a mismatch, got %v", err)
+	}
+	if !password.NeedsRehash(legacyHash, hashers["scrypt"]) {
+		t.Fatal("legacy hashes should always be rehashed")
+	}
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	weak, _ := password.Scrypt{LogN: 10, R: 8, P: 1, KeyLen: 16}.Hash("pw")
+	if !password.NeedsRehash(weak, hashers["scrypt"]) {
+		t.Fatal("a shorter key should need a rehash")
+	}
+	if !password.NeedsRehash(weak, hashers["argon2id"]) {
+		t.Fatal("another algorithm should need a rehash")
+	}
+	strong, _ := password.Scrypt{LogN: 11, R: 8, P: 1, KeyLen: 32}.Hash("pw")
+	if password.NeedsRehash(strong, hashers["scrypt"]) {
+		t.Fatal("stronger parameters should not need a rehash")
+	}
+}
+
+func TestMalformedPasswordHashes(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"plain",
+		"$disto$",
+		"$disto$14$8$",
+		"$disto$14$8$short",
+		"$disto$x$8$XhJF+pYLE2njYLd1Gk/0VmIt2hnieqI2ey2XlN8a1RN0P/24kHQHw",
+		"$disto$40$8$XhJF+pYLE2njYLd1Gk/0VmIt2hnieqI2ey2XlN8a1RN0P/24kHQHw",
+		"$scrypt$ln=10,r=8$c2FsdA$a2V5",
+		"$scrypt$ln=99,r=8,p=1$c2FsdA$a2V5",
+		"$scrypt$ln=10,r=8,p=1$!!$a2V5",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$2a$04$short",
+		"$md5$abc",
+	} {
+		err := password.Verify("pw", hash)
+		if err == nil || errors.Is(err, password.MismatchError) {
+			t.Fatalf("expected %q to be rejected as malformed, got %v", hash, err)
+		}
+		if !password.NeedsRehash(hash, hashers["scrypt"]) {
+			t.Fatalf("expected %q to need a rehash", hash)
+		}
+	}
+}
+
+func TestPepperedPasswordHashes(t *testing.T) {
+	current, err := password.NewPepper("2024", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, _ := password.NewPepper("2023", []byte("fedcba9876543210fedcba9876543210"))
+	peppers := map[string]password.Pepper{current.ID: current, previous.ID: previous}
+
+	for name, hasher := range hashers {
+		hash, err := current.Hash(hasher, "correct horse")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if password.PepperID(hash) != "2024" {
+			t.Fatalf("%s: expected the key ID in %q", name, hash)
+		}
+		if err := password.VerifyWithPeppers("correct horse", hash, peppers); err != nil {
+			t.Fatalf("%s: expected %q to verify: %v", name, hash, err)
+		}
+		if err := password.VerifyWithPeppers("wrong horse", hash, peppers); !errors.Is(err, password.MismatchError) {
+			t.Fatalf("%s: expected a mismatch, got %v", name, err)
+		}
+		if err := password.Verify("correct horse", hash); !errors.Is(err, password.UnknownPepperError) {
+			t.Fatalf("%s: expected the pepper to be required, got %v", name, err)
+		}
+		if password.NeedsRehash(hash, hasher) {
+			t.Fatalf("%s: the pepper should not change the parameters of the hash", name)
+		}
+	}
+
+	old, _ := previous.Hash(hashers["scrypt"], "correct horse")
+	if err := password.VerifyWithPeppers("correct horse", old, peppers); err != nil {
+		t.Fatalf("expected a hash of the previous pepper to verify: %v", err)
+	}
+	delete(peppers, previous.ID)
+	if err := password.VerifyWithPeppers("correct horse", old, peppers); !errors.Is(err, password.UnknownPepperError) {
+		t.Fatalf("expected an unknown pepper, got %v", err)
+	}
+	if _, err := password.NewPepper("bad id!", []byte("0123456789abcdef")); err == nil {
+		t.Fatal("expected an invalid key ID to be rejected")
+	}
+}
+
+func TestParsePeppers(t *testing.T) {
+	current, all, err := password.ParsePeppers("2024:MDEyMzQ1Njc4OWFiY2RlZg==, 2023:ZmVkY2JhOTg3NjU0MzIxMA==\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current == nil || current.ID != "2024" || string(current.Key) != "0123456789abcdef" {
+		t.Fatalf("expected the first entry to be the current pepper, got %+v", current)
+	}
+	if len(all) != 2 || string(all["2023"].Key) != "fedcba9876543210" {
+		t.Fatalf("expected both peppers, got %v", all)
+	}
+
+	current, all, err = password.ParsePeppers("")
+	if err != nil || current != nil || len(all) != 0 {
+		t.Fatalf("expected no pepper for an empty list, got %v, %v, %v", current, all, err)
+	}
+
+	for _, list := range []string{
+		"2024:not base64!",
+		"2024:c2hvcnQ=",
+		"bad id!:MDEyMzQ1Njc4OWFiY2RlZg==",
+		"2024:MDEyMzQ1Njc4OWFiY2RlZg== 2024:ZmVkY2JhOTg3NjU0MzIxMA==",
+	} {
+		if _, _, err := password.ParsePeppers(list); err == nil {
+			t.Fatalf("expected %q to be rejected", list)
+		}
+	}
+}
+
+func TestHashSlotsSaturation(t *testing.T) {
+	slots := password.NewSlots(1, 10*time.Millisecond)
+
+	held, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- slots.Do(func() error {
+			close(held)
+			<-release
+			return nil
+		})
+	}()
+	<-held
+
+	if err := slots.Do(func() error { return nil }); !errors.Is(err, password.BusyError) {
+		t.Fatalf("expected the slots to be busy, got %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	ran := false
+	if err := slots.Do(func() error { ran = true; return nil }); err != nil || !ran {
+		t.Fatalf("expected a free slot, got %v", err)
+	}
+}
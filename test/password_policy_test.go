@@ -0,0 +1,96 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"coderero.dev/projects/go/gin/hello/pkg/password"
+)
+
+var passwordPolicy = password.Policy{MinLength: 8, MaxLength: 16, MinClasses: 3}
+
+func TestPasswordPolicyAcceptsStrongPassword(t *testing.T) {
+	errs, err := passwordPolicy.Check("password", "Tr0mbone-Kite", "jane", "jane.doe@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+}
+
+func TestPasswordPolicyReportsEveryBrokenRule(t *testing.T) {
+	errs, err := passwordPolicy.Check("new_password", "janedoe", "janedoe", "jane@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Too short, too few character classes and the username.
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", errs)
+	}
+	for _, e := range errs {
+		if e.Field != "new_password" || e.Message == "" {
+			t.Fatalf("unexpected error: %+v", e)
+		}
+	}
+
+	errs, _ = passwordPolicy.Check("password", "Aa1!aaaaaaaaaaaaa", "john", "john@example.com")
+	if len(errs) != 1 {
+		t.Fatalf("expected the maximum length error, got %v", errs)
+	}
+}
+
+func TestPasswordPolicyCountsMaxBytesInBytes(t *testing.T) {
+	policy := password.Policy{MinLength: 8, MaxLength: 72, MaxBytes: 72}
+
+	// 36 characters, but 72 bytes of UTF-8.
+	errs, _ := policy.Check("password", strings.Repeat("é", 35)+"ü", "jane", "jane@example.com")
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+
+	// 37 characters, but 74 bytes of UTF-8, which bcrypt can not hash.
+	errs, _ = policy.Check("password", strings.Repeat("é", 37), "jane", "jane@example.com")
+	if len(errs) != 1 {
+		t.Fatalf("expected the maximum bytes error, got %v", errs)
+	}
+	if _, err := (password.Bcrypt{Cost: 4}).Hash(strings.Repeat("é", 37)); err == nil {
+		t.Fatal("expected bcrypt to reject the password")
+	}
+}
+
+func TestPasswordPolicyRejectsEmailLocalPart(t *testing.T) {
+	errs, _ := passwordPolicy.Check("password", "xX-Jane.Doe-9", "jd", "Jane.Doe@example.com")
+	if len(errs) != 1 {
+		t.Fatalf("expected the personal information error, got %v", errs)
+	}
+}
+
+func TestBreachedListFindsRangeFileEntries(t *testing.T) {
+	dir := t.TempDir()
+	name, entry := password.RangeFile("Summer2024!")
+	contents := "0000000000000000000000000000000000A:3\r\n" + entry + "\r\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	list := &password.BreachedList{Dir: dir}
+	for pw, want := range map[string]bool{"Summer2024!": true, "Winter2024!": false} {
+		got, err := list.Contains(pw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("Contains(%q) = %v, want %v", pw, got, want)
+		}
+	}
+
+	strict := passwordPolicy
+	strict.Breached = list
+	errs, _ := strict.Check("password", "Summer2024!", "jane", "jane@example.com")
+	if len(errs) != 1 {
+		t.Fatalf("expected the breached password error, got %v", errs)
+	}
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"coderero.dev/projects/go/gin/hello/pkg/tokenfamily"
+)
+
+func TestTokenFamilyRotation(t *testing.T) {
+	store := tokenfamily.NewMemoryStore()
+	if err := tokenfamily.Start(store, "family", "rt-1", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tokenfamily.Rotate(store, "family", "rt-1", "rt-2", time.Hour); err != nil {
+		t.Fatalf("expected the current token to rotate: %v", err)
+	}
+	if tokenfamily.IsCurrent(store, "family", "rt-1") || !tokenfamily.IsCurrent(store, "family", "rt-2") {
+		t.Fatal("expected the rotated token to be the current one")
+	}
+	if err := tokenfamily.Rotate(store, "family", "rt-2", "rt-3", time.Hour); err != nil {
+		t.Fatalf("expected the current token to rotate: %v", err)
+	}
+}
+
+func TestTokenFamilyReuseRevokesFamily(t *testing.T) {
+	store := tokenfamily.NewMemoryStore()
+	tokenfamily.Start(store, "family", "rt-1", time.Hour)
+	tokenfamily.Rotate(store, "family", "rt-1", "rt-2", time.Hour)
+
+	if err := tokenfamily.Rotate(store, "family", "rt-1", "rt-3", time.Hour); !errors.Is(err, tokenfamily.ReusedError) {
+		t.Fatalf("expected the reuse to be detected, got %v", err)
+	}
+	if tokenfamily.IsCurrent(store, "family", "rt-2") {
+		t.Fatal("expected the family to be revoked")
+	}
+	if err := tokenfamily.Rotate(store, "family", "rt-2", "rt-4", time.Hour); !errors.Is(err, tokenfamily.NotFoundError) {
+		t.Fatalf("expected the legitimate token to be revoked as well, got %v", err)
+	}
+}
+
+func TestTokenFamilyExpires(t *testing.T) {
+	store := tokenfamily.NewMemoryStore()
+	tokenfamily.Start(store, "family", "rt-1", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if err := tokenfamily.Rotate(store, "family", "rt-1", "rt-2", time.Hour); !errors.Is(err, tokenfamily.NotFoundError) {
+		t.Fatalf("expected the family to have expired, got %v", err)
+	}
+}
+
+func TestAccessTokensAreNotRefreshTokens(t *testing.T) {
+	now := time.Now()
+	for _, test := range []struct {
+		name                string
+		family, id, session string
+		expiresAt           time.Time
+		refresh             bool
+	}{
+		{"refresh token", "family", "jti", "sid", now.Add(24 * time.Hour), true},
+		{"access token", "", "jti", "sid", now.Add(5 * time.Minute), false},
+		{"access token without a session", "", "jti", "", now.Add(5 * time.Minute), false},
+		{"legacy refresh token", "", "", "", now.Add(24 * time.Hour), true},
+		{"legacy access token", "", "", "", now.Add(5 * time.Minute), false},
+	} {
+		if refresh := tokenfamily.IsRefreshToken(test.family, test.id, test.session, test.expiresAt, now); refresh != test.refresh {
+			t.Fatalf("%s: expected %v, got %v", test.name, test.refresh, refresh)
+		}
+	}
+}
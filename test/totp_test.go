@@ -0,0 +1,24 @@
+package test
+
+import (
+	"testing"
+
+	"coderero.dev/projects/go/gin/hello/pkg/totp"
+)
+
+// The SHA-1 test vectors of RFC 6238, Appendix B, truncated to six digits.
+func TestTOTPCodeVectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	for unix, want := range map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	} {
+		if code := totp.Code(key, unix/totp.Period); code != want {
+			t.Fatalf("at %d: expected %s, got %s", unix, want, code)
+		}
+	}
+}
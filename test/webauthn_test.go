@@ -0,0 +1,241 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"coderero.dev/projects/go/gin/hello/pkg/webauthn"
+	"github.com/fxamacker/cbor/v2"
+)
+
+const (
+	testOrigin = "https://app.example.com"
+	testRPID   = "example.com"
+)
+
+var testRelyingParty = webauthn.RelyingParty{
+	ID:      testRPID,
+	Name:    "Example",
+	Origins: []string{testOrigin},
+}
+
+// The softwareAuthenticator struct is a WebAuthn authenticator with an ES256 credential held in
+// memory, which creates the same responses as a browser with a hardware authenticator.
+type softwareAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+	flags        byte
+	rpID         string
+}
+
+func newSoftwareAuthenticator(t *testing.T) *softwareAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		t.Fatal(err)
+	}
+	// The user is present (0x01) and verified (0x04).
+	return &softwareAuthenticator{key: key, credentialID: id, flags: 0x05, rpID: testRPID}
+}
+
+func (a *softwareAuthenticator) clientData(t *testing.T, ceremony string, origin string, challenge []byte) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    origin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func (a *softwareAuthenticator) authenticatorData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attested...)
+}
+
+func (a *softwareAuthenticator) create(t *testing.T, origin string, challenge []byte) webauthn.AttestationResponse {
+	t.Helper()
+	publicKey, err := cbor.Marshal(map[int]any{
+		1:  2,
+		3:  webauthn.ES256,
+		-1: 1,
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attested := make([]byte, 16)
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, publicKey...)
+
+	attestation, err := cbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authenticatorData(a.flags|0x40, attested),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var response webauthn.AttestationResponse
+	response.ID = base64.RawURLEncoding.EncodeToString(a.credentialID)
+	response.RawID = a.credentialID
+	response.Type = "public-key"
+	response.Response.ClientDataJSON = a.clientData(t, "webauthn.create", origin, challenge)
+	response.Response.AttestationObject = attestation
+	return response
+}
+
+func (a *softwareAuthenticator) get(t *testing.T, origin string, challenge []byte) webauthn.AssertionResponse {
+	t.Helper()
+	a.signCount++
+	clientData := a.clientData(t, "webauthn.get", origin, challenge)
+	authData := a.authenticatorData(a.flags, nil)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var response webauthn.AssertionResponse
+	response.ID = base64.RawURLEncoding.EncodeToString(a.credentialID)
+	response.RawID = a.credentialID
+	response.Type = "public-key"
+	response.Response.ClientDataJSON = clientData
+	response.Response.AuthenticatorData = authData
+	response.Response.Signature = signature
+	return response
+}
+
+func newChallenge(t *testing.T) []byte {
+	t.Helper()
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return challenge
+}
+
+func register(t *testing.T, authenticator *softwareAuthenticator) *webauthn.Credential {
+	t.Helper()
+	challenge := newChallenge(t)
+	credential, err := testRelyingParty.VerifyRegistration(challenge, authenticator.create(t, testOrigin, challenge), true)
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+	return credential
+}
+
+func TestWebAuthnRegistrationAndLogin(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	credential := register(t, authenticator)
+
+	for i := 1; i <= 2; i++ {
+		challenge := newChallenge(t)
+		signCount, err := testRelyingParty.VerifyAssertion(challenge, *credential, authenticator.get(t, testOrigin, challenge), true)
+		if err != nil {
+			t.Fatalf("login %d failed: %v", i, err)
+		}
+		if signCount != uint32(i) {
+			t.Fatalf("expected sign count %d, got %d", i, signCount)
+		}
+		credential.SignCount = signCount
+	}
+}
+
+func TestWebAuthnRegistrationRejectsInvalidResponses(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*softwareAuthenticator)
+		origin string
+		want   error
+	}{
+		{name: "wrong origin", origin: "https://evil.example.net", want: webauthn.OriginMismatchError},
+		{name: "wrong relying party", origin: testOrigin, modify: func(a *softwareAuthenticator) { a.rpID = "evil.example.net" }, want: webauthn.RPIDMismatchError},
+		{name: "user not present", origin: testOrigin, modify: func(a *softwareAuthenticator) { a.flags = 0 }, want: webauthn.UserNotPresentError},
+		{name: "user not verified", origin: testOrigin, modify: func(a *softwareAuthenticator) { a.flags = 0x01 }, want: webauthn.UserNotVerifiedError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := newSoftwareAuthenticator(t)
+			if tt.modify != nil {
+				tt.modify(authenticator)
+			}
+			challenge := newChallenge(t)
+			_, err := testRelyingParty.VerifyRegistration(challenge, authenticator.create(t, tt.origin, challenge), true)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestWebAuthnLoginRejectsWrongChallenge(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	credential := register(t, authenticator)
+
+	_, err := testRelyingParty.VerifyAssertion(newChallenge(t), *credential, authenticator.get(t, testOrigin, newChallenge(t)), true)
+	if !errors.Is(err, webauthn.ChallengeMismatchError) {
+		t.Fatalf("expected %v, got %v", webauthn.ChallengeMismatchError, err)
+	}
+}
+
+func TestWebAuthnLoginRejectsForeignCredential(t *testing.T) {
+	credential := register(t, newSoftwareAuthenticator(t))
+	other := newSoftwareAuthenticator(t)
+	other.credentialID = credential.ID
+
+	challenge := newChallenge(t)
+	_, err := testRelyingParty.VerifyAssertion(challenge, *credential, other.get(t, testOrigin, challenge), true)
+	if !errors.Is(err, webauthn.InvalidSignatureError) {
+		t.Fatalf("expected %v, got %v", webauthn.InvalidSignatureError, err)
+	}
+}
+
+func TestWebAuthnLoginRequiresUserVerification(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	credential := register(t, authenticator)
+	authenticator.flags = 0x01
+
+	challenge := newChallenge(t)
+	_, err := testRelyingParty.VerifyAssertion(challenge, *credential, authenticator.get(t, testOrigin, challenge), true)
+	if !errors.Is(err, webauthn.UserNotVerifiedError) {
+		t.Fatalf("expected %v, got %v", webauthn.UserNotVerifiedError, err)
+	}
+}
+
+func TestWebAuthnLoginDetectsClonedAuthenticator(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	credential := register(t, authenticator)
+	credential.SignCount = 5
+
+	challenge := newChallenge(t)
+	_, err := testRelyingParty.VerifyAssertion(challenge, *credential, authenticator.get(t, testOrigin, challenge), true)
+	if !errors.Is(err, webauthn.SignCountError) {
+		t.Fatalf("expected %v, got %v", webauthn.SignCountError, err)
+	}
+}
@@ -3,4 +3,15 @@ package types
 // This is a constant variable that stores the value of the `Content-Type` header for JSON requests.
 const (
 	Application_json string = "application/json"
+	Application_form string = "application/x-www-form-urlencoded"
+)
+
+// These constants are the keys under which the authentication middleware stores the subject and the
+// scope of the access token, the ID of the current session and the ID of the authenticated OAuth client
+// in the request context.
+const (
+	ContextSubject   string = "sub"
+	ContextScope     string = "scope"
+	ContextSessionID string = "session_id"
+	ContextClientID  string = "client_id"
 )
@@ -1,10 +1,11 @@
 package types
 
-// The SignUp struct is used to bind the request body form to the struct.
+// The SignUp struct is used to bind the request body form to the struct. The password is checked
+// against the password policy once the body is valid.
 type Register struct {
 	Username  string `json:"username" validate:"required,min=3,max=32,alphanum"`
 	Email     string `json:"email" validate:"required,email"`
-	Password  string `json:"password" validate:"required,min=8"`
+	Password  string `json:"password" validate:"required"`
 	FirstName string `json:"first_name" validate:"required,alpha"`
 	LastName  string `json:"last_name" validate:"required,alpha"`
 	Age       int    `json:"age" validate:"required,gt=0,lt=100"`
@@ -16,3 +17,47 @@ type Login struct {
 	Email    string `json:"email" validate:"omitempty,email"`
 	Password string `json:"password" validate:"required,min=8"`
 }
+
+// The TOTPCode struct is used to bind the request body form to the struct.
+type TOTPCode struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// The MFAVerify struct is used to bind the request body form to the struct. Either a TOTP code or a
+// recovery code has to be given.
+type MFAVerify struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code,omitempty,max=32"`
+}
+
+// The ResendVerification struct is used to bind the request body form to the struct.
+type ResendVerification struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// The ForgotPassword struct is used to bind the request body form to the struct.
+type ForgotPassword struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// The ResetPassword struct is used to bind the request body form to the struct.
+type ResetPassword struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// The MagicLink struct is used to bind the request body form to the struct.
+type MagicLink struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// The OAuthConsent struct is used to bind the request body form to the struct. It repeats the
+// authorization request the user is asked to consent to.
+type OAuthConsent struct {
+	ClientID    string `json:"client_id" validate:"required"`
+	RedirectURI string `json:"redirect_uri"`
+	Scope       string `json:"scope"`
+	State       string `json:"state"`
+	Approve     bool   `json:"approve"`
+}